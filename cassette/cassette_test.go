@@ -0,0 +1,212 @@
+package cassette_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hm-edu/harica/cassette"
+	"github.com/hm-edu/harica/client"
+	"github.com/hm-edu/harica/fake"
+	"github.com/hm-edu/harica/models"
+)
+
+var update = flag.Bool("update", false, "record "+genCertCassette+" again against the fake portal")
+
+const (
+	genCertCassette   = "testdata/gen-cert.json"
+	requesterEmail    = "requester@example.org"
+	requesterPassword = "requester-secret"
+	validatorEmail    = "validator@example.org"
+	validatorPassword = "validator-secret"
+	domain            = "www.example.org"
+)
+
+// genCert requests a certificate for domain, approves it and downloads it,
+// like the gen-cert command.
+func genCert(t *testing.T, baseURL string, middleware func(http.RoundTripper) http.RoundTripper) *models.CertificateResponse {
+	t.Helper()
+	ctx := context.Background()
+	options := []client.Option{
+		client.WithBaseURL(baseURL),
+		client.WithBackgroundRefresh(false),
+		client.WithTransportMiddleware(middleware),
+	}
+	requester, err := client.NewClient(requesterEmail, requesterPassword, "", options...)
+	if err != nil {
+		t.Fatalf("requester login: %v", err)
+	}
+	validator, err := client.NewClient(validatorEmail, validatorPassword, "", options...)
+	if err != nil {
+		t.Fatalf("validator login: %v", err)
+	}
+
+	domains, err := requester.CheckDomainNames(ctx, []string{domain})
+	if err != nil {
+		t.Fatalf("CheckDomainNames: %v", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := client.NewCSR(key, []string{domain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction, err := requester.RequestCertificate(ctx, domains, csr, "DV")
+	if err != nil {
+		t.Fatalf("RequestCertificate: %v", err)
+	}
+	reviews, err := validator.GetPendingReviews(ctx)
+	if err != nil {
+		t.Fatalf("GetPendingReviews: %v", err)
+	}
+	for _, review := range reviews {
+		if review.TransactionID != transaction.TransactionID {
+			continue
+		}
+		for _, dto := range review.ReviewGetDTOs {
+			if err := validator.ApproveRequest(ctx, dto.ReviewID, "Auto Approval", dto.ReviewValue); err != nil {
+				t.Fatalf("ApproveRequest: %v", err)
+			}
+		}
+	}
+	cert, err := requester.GetCertificate(ctx, transaction.TransactionID)
+	if err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	for _, c := range []*client.Client{requester, validator} {
+		if err := c.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+	return cert
+}
+
+// record writes genCertCassette from a session with the fake portal.
+func record(t *testing.T) {
+	t.Helper()
+	srv := fake.NewServer()
+	defer srv.Close()
+	srv.AddUser(fake.User{Email: requesterEmail, Password: requesterPassword, Roles: []fake.Role{fake.RoleRequester}})
+	srv.AddUser(fake.User{Email: validatorEmail, Password: validatorPassword, Roles: []fake.Role{fake.RoleValidator}})
+	if err := os.Remove(genCertCassette); err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	genCert(t, srv.URL, cassette.NewRecorder(genCertCassette).Middleware)
+}
+
+func TestReplay(t *testing.T) {
+	if *update {
+		record(t)
+	}
+	cas, err := cassette.Load(genCertCassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := cassette.NewReplayer(cas)
+	cert := genCert(t, "https://harica.invalid", replayer.Middleware)
+	if cert.PemBundle == "" {
+		t.Error("replayed certificate has no PEM bundle")
+	}
+	if n := replayer.Remaining(); n != 0 {
+		t.Errorf("%d interactions were not replayed", n)
+	}
+}
+
+func TestReplayWithoutInteraction(t *testing.T) {
+	replayer := cassette.NewReplayer(&cassette.Cassette{})
+	_, err := client.NewClient(requesterEmail, requesterPassword, "",
+		client.WithBaseURL("https://harica.invalid"),
+		client.WithBackgroundRefresh(false),
+		client.WithTransportMiddleware(replayer.Middleware))
+	if !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Fatalf("NewClient = %v, want %v", err, cassette.ErrNoInteraction)
+	}
+}
+
+func TestCassetteIsSanitized(t *testing.T) {
+	data, err := os.ReadFile(genCertCassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{requesterPassword, validatorPassword, "BEGIN CERTIFICATE REQUEST"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+}
+
+// schemas maps the recorded endpoints to the models their responses decode
+// into. As genCertCassette was recorded against the fake portal, checking it
+// tests CheckSchema, not the models against HARICA.
+var schemas = []struct {
+	path  string
+	model any
+}{
+	{client.CheckDomainNamesPath, &[]models.DomainResponse{}},
+	{client.RequestCertPath, &models.CertificateRequestResponse{}},
+	{client.ReviewablePath, &[]models.ReviewResponse{}},
+	{client.GetCertificatePath, &models.CertificateResponse{}},
+}
+
+func TestCheckSchema(t *testing.T) {
+	cas, err := cassette.Load(genCertCassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, schema := range schemas {
+		if err := cas.CheckSchema(http.MethodPost, schema.path, schema.model); err != nil {
+			t.Errorf("CheckSchema(%s): %v", schema.path, err)
+		}
+	}
+}
+
+func TestCheckSchemaDetectsDrift(t *testing.T) {
+	cas, err := cassette.Load(genCertCassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drift := map[string]func(map[string]any){
+		"new field":    func(body map[string]any) { body["newField"] = true },
+		"changed type": func(body map[string]any) { body["isRevoked"] = "no" },
+	}
+	for name, change := range drift {
+		t.Run(name, func(t *testing.T) {
+			changed := &cassette.Cassette{}
+			for _, interaction := range cas.Interactions {
+				if interaction.Request.Path == client.GetCertificatePath {
+					var body map[string]any
+					if err := json.Unmarshal([]byte(interaction.Response.Body), &body); err != nil {
+						t.Fatal(err)
+					}
+					change(body)
+					data, err := json.Marshal(body)
+					if err != nil {
+						t.Fatal(err)
+					}
+					interaction.Response.Body = string(data)
+				}
+				changed.Interactions = append(changed.Interactions, interaction)
+			}
+			if err := changed.CheckSchema(http.MethodPost, client.GetCertificatePath, &models.CertificateResponse{}); err == nil {
+				t.Error("CheckSchema did not detect the change")
+			}
+		})
+	}
+}
+
+func TestCheckSchemaRequiresPointer(t *testing.T) {
+	cas := &cassette.Cassette{}
+	if err := cas.CheckSchema(http.MethodPost, client.GetCertificatePath, models.CertificateResponse{}); err == nil {
+		t.Error("CheckSchema accepted a non-pointer model")
+	}
+}
@@ -0,0 +1,130 @@
+package cassette
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hm-edu/harica/internal/redact"
+)
+
+// Recorder captures the interactions of one or more clients and writes them
+// to a cassette file after every response.
+type Recorder struct {
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a recorder that writes to path. An existing file is
+// overwritten.
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Middleware wraps next so that its traffic is recorded. It is meant for
+// client.WithTransportMiddleware.
+func (r *Recorder) Middleware(next http.RoundTripper) http.RoundTripper {
+	return &recordingTransport{next: next, recorder: r}
+}
+
+// Cassette returns a copy of the interactions recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+func (r *Recorder) add(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	return r.cassette.Save(r.path)
+}
+
+type recordingTransport struct {
+	next     http.RoundTripper
+	recorder *Recorder
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		reqBody, err = io.ReadAll(body)
+		body.Close() //nolint:errcheck
+		if err != nil {
+			return nil, err
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	err = t.recorder.add(Interaction{
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.RawQuery,
+			Header: sanitizeHeader(req.Header),
+			Body:   redact.BodyWithTokens(string(reqBody), neverExpiring),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     sanitizeHeader(resp.Header),
+			Body:       redact.BodyWithTokens(string(respBody), neverExpiring),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// volatileHeaders differ between runs and carry no information for replay.
+var volatileHeaders = []string{"Date", "User-Agent", "Content-Length", "Traceparent", "Tracestate"}
+
+func sanitizeHeader(h http.Header) http.Header {
+	h = redact.Header(h)
+	for _, name := range volatileHeaders {
+		h.Del(name)
+	}
+	if len(h) == 0 {
+		return nil
+	}
+	return h
+}
+
+// replayExpiry is far enough in the future for recorded tokens to outlive
+// any test suite.
+var replayExpiry = time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// neverExpiring replaces a session token by an unsigned token with the same
+// claims that expires at replayExpiry. Tokens that cannot be parsed are
+// redacted completely.
+func neverExpiring(token string) string {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return redact.Placeholder
+	}
+	claims["exp"] = replayExpiry.Unix()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		return redact.Placeholder
+	}
+	return signed
+}
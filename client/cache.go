@@ -0,0 +1,139 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+)
+
+// CachedSession is the state of a logged in session that is required to
+// resume it in another process.
+type CachedSession struct {
+	Token             string         `json:"token"`
+	VerificationToken string         `json:"verificationToken"`
+	Cookies           []*http.Cookie `json:"cookies"`
+}
+
+// SessionCache persists sessions between client instances. Load returns
+// nil and no error if there is no session for key. Storing a nil session
+// removes the session for key.
+type SessionCache interface {
+	Load(key string) (*CachedSession, error)
+	Store(key string, session *CachedSession) error
+}
+
+// WithSessionCache makes the client resume sessions from cache instead of
+// logging in, as long as the cached token is not about to expire. New
+// sessions are written back to the cache.
+func WithSessionCache(cache SessionCache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// FileSessionCache stores one file per account in Dir. The files are only
+// readable by the current user and, if Passphrase is set, encrypted with it.
+type FileSessionCache struct {
+	Dir        string
+	Passphrase string
+}
+
+func NewFileSessionCache(dir, passphrase string) *FileSessionCache {
+	return &FileSessionCache{Dir: dir, Passphrase: passphrase}
+}
+
+func (f *FileSessionCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (f *FileSessionCache) Load(key string) (*CachedSession, error) {
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.Passphrase != "" {
+		identity, err := age.NewScryptIdentity(f.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		r, err := age.Decrypt(bytes.NewReader(data), identity)
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var session CachedSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (f *FileSessionCache) Store(key string, session *CachedSession) error {
+	if session == nil {
+		err := os.Remove(f.path(key))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	if f.Passphrase != "" {
+		recipient, err := age.NewScryptRecipient(f.Passphrase)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		w, err := age.Encrypt(&buf, recipient)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+	if err := os.MkdirAll(f.Dir, 0o700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so concurrent readers never see a
+	// partially written session.
+	tmp, err := os.CreateTemp(f.Dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
@@ -0,0 +1,100 @@
+package client_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hm-edu/harica/client"
+)
+
+var cachedSession = &client.CachedSession{
+	Token:             "session-token",
+	VerificationToken: "antiforgery-token",
+	Cookies:           []*http.Cookie{{Name: "session", Value: "cookie-value"}},
+}
+
+// cacheFiles returns the files in dir.
+func cacheFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, entry := range entries {
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	return files
+}
+
+func TestFileSessionCache(t *testing.T) {
+	for name, passphrase := range map[string]string{"plain": "", "encrypted": "cache-passphrase"} {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "sessions")
+			cache := client.NewFileSessionCache(dir, passphrase)
+
+			if session, err := cache.Load(requesterEmail); session != nil || err != nil {
+				t.Fatalf("Load() of a missing session = %v, %v", session, err)
+			}
+			if err := cache.Store(requesterEmail, cachedSession); err != nil {
+				t.Fatalf("Store: %v", err)
+			}
+			if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0o700 {
+				t.Errorf("directory = %v, %v, want mode 700", info, err)
+			}
+			files := cacheFiles(t, dir)
+			if len(files) != 1 {
+				t.Fatalf("cache holds %v, want one file", files)
+			}
+			info, err := os.Stat(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); mode != 0o600 {
+				t.Errorf("file mode = %o, want 600", mode)
+			}
+			data, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encrypted := !strings.Contains(string(data), cachedSession.Token); encrypted != (passphrase != "") {
+				t.Errorf("file is encrypted = %t with passphrase %q", encrypted, passphrase)
+			}
+
+			session, err := cache.Load(requesterEmail)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(session, cachedSession) {
+				t.Errorf("Load() = %+v, want %+v", session, cachedSession)
+			}
+			if session, err := cache.Load(validatorEmail); session != nil || err != nil {
+				t.Errorf("Load() of another account = %v, %v", session, err)
+			}
+
+			if err := cache.Store(requesterEmail, nil); err != nil {
+				t.Fatalf("Store(nil): %v", err)
+			}
+			if files := cacheFiles(t, dir); len(files) != 0 {
+				t.Errorf("cache holds %v after removal", files)
+			}
+			if err := cache.Store(requesterEmail, nil); err != nil {
+				t.Errorf("Store(nil) of a missing session: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileSessionCacheWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	if err := client.NewFileSessionCache(dir, "cache-passphrase").Store(requesterEmail, cachedSession); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if _, err := client.NewFileSessionCache(dir, "wrong").Load(requesterEmail); err == nil {
+		t.Error("Load() with a wrong passphrase succeeded")
+	}
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Roles as they appear in the role claim of session tokens.
+const (
+	RoleUser                  = "User"
+	RoleSSLEnterpriseApprover = "SSLEnterpriseApprover"
+	RoleEnterpriseAdmin       = "EnterpriseAdmin"
+)
+
+// ASP.NET Core issues some claims under their WS-Federation names instead of
+// the short JWT names.
+const (
+	emailClaimURI = "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress"
+	roleClaimURI  = "http://schemas.microsoft.com/ws/2008/06/identity/claims/role"
+)
+
+// Claims are the claims of a session token. The token is issued by HARICA
+// and not verified by the client.
+type Claims struct {
+	Subject      string
+	Email        string
+	Organization string
+	Roles        []string
+	IssuedAt     time.Time
+	ExpiresAt    time.Time
+}
+
+// HasRole reports whether the session has the given role.
+func (c Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// Capabilities are the operations a session is expected to be allowed to
+// perform, derived from its roles.
+type Capabilities struct {
+	// RequestCertificates is set for accounts with the User role.
+	RequestCertificates bool
+	// ReviewRequests is set for SSL Enterprise Approvers.
+	ReviewRequests bool
+	// AdministerEnterprise is set for Enterprise Admins.
+	AdministerEnterprise bool
+}
+
+// SessionInfo describes the current session of a Client.
+type SessionInfo struct {
+	Claims       Claims
+	Capabilities Capabilities
+}
+
+// Session returns the claims and capabilities of the current session.
+func (c *Client) Session() (SessionInfo, error) {
+	if c.isClosed() {
+		return SessionInfo{}, ErrClosed
+	}
+	_, token := c.session()
+	if token == "" {
+		return SessionInfo{}, errors.New("harica: not logged in")
+	}
+	claims, err := parseClaims(token)
+	if err != nil {
+		return SessionInfo{}, err
+	}
+	return SessionInfo{
+		Claims: claims,
+		Capabilities: Capabilities{
+			RequestCertificates:  claims.HasRole(RoleUser),
+			ReviewRequests:       claims.HasRole(RoleSSLEnterpriseApprover),
+			AdministerEnterprise: claims.HasRole(RoleEnterpriseAdmin),
+		},
+	}, nil
+}
+
+// parseClaims extracts the claims of token without verifying it.
+func parseClaims(token string) (Claims, error) {
+	mapClaims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, mapClaims); err != nil {
+		return Claims{}, err
+	}
+	var claims Claims
+	claims.Subject, _ = mapClaims.GetSubject()
+	if iat, err := mapClaims.GetIssuedAt(); err == nil && iat != nil {
+		claims.IssuedAt = iat.Time
+	}
+	if exp, err := mapClaims.GetExpirationTime(); err == nil && exp != nil {
+		claims.ExpiresAt = exp.Time
+	}
+	claims.Email = stringClaim(mapClaims, "email", emailClaimURI)
+	claims.Organization = stringClaim(mapClaims, "organization")
+	for _, name := range []string{"role", roleClaimURI} {
+		switch v := mapClaims[name].(type) {
+		case string:
+			claims.Roles = append(claims.Roles, v)
+		case []any:
+			for _, role := range v {
+				if role, ok := role.(string); ok {
+					claims.Roles = append(claims.Roles, role)
+				}
+			}
+		}
+	}
+	return claims, nil
+}
+
+// stringClaim returns the first of the given claims that is a string.
+func stringClaim(claims jwt.MapClaims, names ...string) string {
+	for _, name := range names {
+		if v, ok := claims[name].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
@@ -1,65 +1,137 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"log/slog"
+	"net/http"
+	"sync"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/go-resty/resty/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/hm-edu/harica/models"
-	"github.com/pquerna/otp/totp"
+	"github.com/jonboulle/clockwork"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 const (
+	// BaseURL is the production HARICA portal used unless WithBaseURL is given.
 	BaseURL               = "https://cm.harica.gr"
 	LoginPath             = "/api/User/Login"
 	LoginPathTotp         = "/api/User/Login2FA"
+	LogoutPath            = "/api/User/Logout"
 	RevocationReasonsPath = "/api/Certificate/GetRevocationReasons"
 	DomainValidationsPath = "/api/ServerCertificate/GetDomainValidations"
+	CheckMatchingOrgPath  = "/api/ServerCertificate/CheckMachingOrganization"
+	CheckDomainNamesPath  = "/api/ServerCertificate/CheckDomainNames"
+	RequestCertPath       = "/api/ServerCertificate/RequestServerCertificate"
+	GetCertificatePath    = "/api/Certificate/GetCertificate"
+	ReviewablePath        = "/api/OrganizationValidatorSSL/GetSSLReviewableTransactions"
+	UpdateReviewsPath     = "/api/OrganizationValidatorSSL/UpdateReviews"
+	RevokeCertificatePath = "/api/Certificate/RevokeCertificate"
+	MyTransactionsPath    = "/api/ServerCertificate/GetMyTransactions"
+	CancelTransactionPath = "/api/Transaction/CancelTransaction"
 	ApplicationJson       = "application/json"
 	RefreshInterval       = 15 * time.Minute
 )
 
+// RevocationReasonName names a reason for revoking a certificate. It is the
+// Name of one of the models.RevocationReason values returned by
+// GetRevocationReasons.
+type RevocationReasonName string
+
+const (
+	ReasonUnspecified          RevocationReasonName = "unspecified"
+	ReasonKeyCompromise        RevocationReasonName = "keyCompromise"
+	ReasonAffiliationChanged   RevocationReasonName = "affiliationChanged"
+	ReasonSuperseded           RevocationReasonName = "superseded"
+	ReasonCessationOfOperation RevocationReasonName = "cessationOfOperation"
+)
+
 type Client struct {
-	client       *resty.Client
-	scheduler    gocron.Scheduler
-	currentToken string
-	debug        bool
+	// mu guards client, currentToken and account, which are replaced on every
+	// login, as well as the refresh status, closed and scheduler.
+	mu                    sync.RWMutex
+	client                *resty.Client
+	currentToken          string
+	account               string
+	lastRefresh           time.Time
+	lastSuccessfulRefresh time.Time
+	lastRefreshErr        error
+	closed                bool
+	// loginMu serializes logins so concurrent auth failures log in only once.
+	loginMu     sync.Mutex
+	scheduler   gocron.Scheduler
+	credentials CredentialProvider
+	otp         OTPProvider
+	clock       clockwork.Clock
+	debug       bool
+	logger      *slog.Logger
+	tracer      trace.Tracer
+	metrics     *Metrics
+	limiters    []*rate.Limiter
+	baseURL     string
+	retry       RetryPolicy
+	poll        PollPolicy
+	cache       SessionCache
+
+	backgroundRefresh bool
+
+	transportConfig transportConfig
+	transport       http.RoundTripper
 }
 
 type Option func(*Client)
 
-type UnexpectedResponseContentTypeError struct {
-	ContentType string
+func NewClient(user, password, totpSeed string, options ...Option) (*Client, error) {
+	return NewClientWithContext(context.Background(), user, password, totpSeed, options...)
 }
 
-func (e *UnexpectedResponseContentTypeError) Error() string {
-	return fmt.Sprintf("unexpected response content type: %s", e.ContentType)
+// NewClientWithContext is like NewClient but uses ctx for the initial login.
+// The context is not retained; background refreshes are not bound to it.
+func NewClientWithContext(ctx context.Context, user, password, totpSeed string, options ...Option) (*Client, error) {
+	creds := StaticCredentials{Email: user, Password: password, TOTPSeed: totpSeed}
+	return NewClientWithCredentials(ctx, creds, options...)
 }
 
-func NewClient(user, password, totpSeed string, options ...Option) (*Client, error) {
-	c := Client{}
+// NewClientWithCredentials creates a client that obtains its credentials from
+// provider for every login.
+func NewClientWithCredentials(ctx context.Context, provider CredentialProvider, options ...Option) (*Client, error) {
+	c := Client{baseURL: BaseURL, credentials: provider, poll: DefaultPollPolicy, backgroundRefresh: true}
 	for _, option := range options {
 		option(&c)
 	}
-	err := c.prepareClient(user, password, totpSeed)
+	if c.logger == nil {
+		c.logger = slog.Default()
+	}
+	if c.tracer == nil {
+		c.tracer = defaultTracer()
+	}
+	if c.clock == nil {
+		c.clock = clockwork.NewRealClock()
+	}
+	c.transport = c.buildTransport()
+	err := c.prepareClient(ctx)
 	if err != nil {
 		return nil, err
 	}
-	s, err := gocron.NewScheduler()
+	c.recordRefresh(nil)
+	c.metrics.observeRefresh(c.accountName(), nil, c.clock.Now())
+	if !c.backgroundRefresh {
+		return &c, nil
+	}
+	s, err := gocron.NewScheduler(gocron.WithClock(c.clock))
 	if err != nil {
 		return nil, err
 	}
 	_, err = s.NewJob(gocron.DurationJob(RefreshInterval), gocron.NewTask(func() {
-		err := c.prepareClient(user, password, totpSeed)
-		if err != nil {
-			slog.Error("failed to prepare client", slog.Any("error", err))
-			return
+		if err := c.refresh(context.Background()); err != nil {
+			c.logger.Error("failed to prepare client", slog.Any("error", err))
 		}
 	}))
 	if err != nil {
@@ -70,253 +142,397 @@ func NewClient(user, password, totpSeed string, options ...Option) (*Client, err
 	return &c, nil
 }
 
+// WithDebug dumps all requests and responses to the logger at debug level.
+// Passwords, one-time codes, tokens, cookies and CSRs are redacted.
 func WithDebug(debug bool) Option {
 	return func(c *Client) {
 		c.debug = debug
 	}
 }
 
-func (c *Client) prepareClient(user, password, totpSeed string) error {
-	renew := false
-
-	if c.currentToken != "" {
-		// Check JWT
-		token, _, err := jwt.NewParser().ParseUnverified(c.currentToken, jwt.MapClaims{})
-		if err != nil {
-			return err
-		}
-		exp, err := token.Claims.GetExpirationTime()
-		if err != nil {
-			return err
-		}
-		if exp.Before(time.Now()) || exp.Before(time.Now().Add(RefreshInterval)) {
-			renew = true
-		}
-	}
-	if c.client == nil || c.currentToken == "" || renew {
-		if totpSeed != "" {
-			return c.loginTotp(user, password, totpSeed)
-		} else {
-			return c.login(user, password)
-		}
+// WithBaseURL points the client at a different HARICA portal, e.g. a staging
+// instance, a path on an egress proxy or a local test double.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
 	}
-	return nil
 }
 
-func (c *Client) loginTotp(user, password, totpSeed string) error {
-	r := resty.New()
-	verificationToken, err := getVerificationToken(r)
-	if err != nil {
-		return err
-	}
-	otp, err := totp.GenerateCode(totpSeed, time.Now())
-	if err != nil {
-		return err
-	}
-	resp, err := r.
-		R().SetHeaderVerbatim("RequestVerificationToken", verificationToken).
-		SetHeader("Content-Type", ApplicationJson).
-		SetBody(map[string]string{"email": user, "password": password, "token": otp}).
-		Post(BaseURL + LoginPathTotp)
-	if err != nil {
-		return err
-	}
-	tokenResp := strings.Trim(resp.String(), "\"")
-	_, _, err = jwt.NewParser().ParseUnverified(tokenResp, jwt.MapClaims{})
-	if err != nil {
-		return err
-	}
-	c.currentToken = tokenResp
-	r = r.SetHeaders(map[string]string{"Authorization": c.currentToken})
-	token, err := getVerificationToken(r)
-	if err != nil {
-		return err
-	}
-	r = r.SetHeaderVerbatim("RequestVerificationToken", token).SetDebug(c.debug)
-	c.client = r
-	return nil
-}
+func (c *Client) GetRevocationReasons(ctx context.Context) (_ []models.RevocationReason, err error) {
+	ctx, span := c.startSpan(ctx, "GetRevocationReasons")
+	defer endSpan(span, &err)
 
-func (c *Client) login(user, password string) error {
-	r := resty.New()
-	verificationToken, err := getVerificationToken(r)
-	if err != nil {
-		return err
-	}
-	resp, err := r.
-		R().SetHeaderVerbatim("RequestVerificationToken", verificationToken).
-		SetHeader("Content-Type", ApplicationJson).
-		SetBody(map[string]string{"email": user, "password": password}).
-		Post(BaseURL + LoginPath)
-	if err != nil {
-		return err
-	}
-	tokenResp := strings.Trim(resp.String(), "\"")
-	_, _, err = jwt.NewParser().ParseUnverified(tokenResp, jwt.MapClaims{})
+	var reasons []models.RevocationReason
+	resp, err := c.do(ctx, request{
+		path: RevocationReasonsPath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetResult(&reasons).
+				ExpectContentType(ApplicationJson)
+		},
+		idempotent: true,
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	c.currentToken = tokenResp
-	r = r.SetHeaders(map[string]string{"Authorization": c.currentToken})
-	token, err := getVerificationToken(r)
-	if err != nil {
-		return err
+	if err := checkContentType(resp); err != nil {
+		return nil, err
 	}
-	r = r.SetHeaderVerbatim("RequestVerificationToken", token).SetDebug(c.debug)
-	c.client = r
-	return nil
+	return reasons, nil
 }
 
-func (c *Client) GetRevocationReasons() error {
-	resp, err := c.client.R().Post(BaseURL + RevocationReasonsPath)
-	if err != nil {
-		return err
-	}
-	data := resp.String()
-	fmt.Print(data)
-	return nil
-}
+func (c *Client) GetDomainValidations(ctx context.Context) (_ []models.DomainValidation, err error) {
+	ctx, span := c.startSpan(ctx, "GetDomainValidations")
+	defer endSpan(span, &err)
 
-func (c *Client) GetDomainValidations() error {
-	resp, err := c.client.R().Post(BaseURL + DomainValidationsPath)
+	var validations []models.DomainValidation
+	resp, err := c.do(ctx, request{
+		path: DomainValidationsPath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetResult(&validations).
+				ExpectContentType(ApplicationJson)
+		},
+		idempotent: true,
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fmt.Print(resp.String())
-	return nil
+	if err := checkContentType(resp); err != nil {
+		return nil, err
+	}
+	return validations, nil
 }
 
 type Domain struct {
 	Domain string `json:"domain"`
 }
 
-func (c *Client) CheckMatchingOrganization(domains []string) ([]models.OrganizationResponse, error) {
+func (c *Client) CheckMatchingOrganization(ctx context.Context, domains []string) (_ []models.OrganizationResponse, err error) {
+	ctx, span := c.startSpan(ctx, "CheckMatchingOrganization")
+	defer endSpan(span, &err)
+
 	var domainDto []Domain
 	for _, domain := range domains {
 		domainDto = append(domainDto, Domain{Domain: domain})
 	}
 	var response []models.OrganizationResponse
-	resp, err := c.client.R().
-		SetHeader("Content-Type", ApplicationJson).
-		ExpectContentType(ApplicationJson).
-		SetResult(&response).SetBody(domainDto).
-		Post(BaseURL + "/api/ServerCertificate/CheckMachingOrganization")
+	resp, err := c.do(ctx, request{
+		path: CheckMatchingOrgPath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetHeader("Content-Type", ApplicationJson).
+				ExpectContentType(ApplicationJson).
+				SetResult(&response).SetBody(domainDto)
+		},
+		idempotent: true,
+	})
 	if err != nil {
 		return nil, err
 	}
-	if !strings.Contains(resp.Header().Get("Content-Type"), ApplicationJson) {
-		return nil, &UnexpectedResponseContentTypeError{ContentType: resp.Header().Get("Content-Type")}
+	if err := checkContentType(resp); err != nil {
+		return nil, err
 	}
 	return response, nil
 }
 
-func (c *Client) GetCertificate(id string) (*models.CertificateResponse, error) {
+func (c *Client) GetCertificate(ctx context.Context, id string) (_ *models.CertificateResponse, err error) {
+	ctx = withTransactionID(ctx, id)
+	ctx, span := c.startSpan(ctx, "GetCertificate")
+	defer endSpan(span, &err)
+
 	var cert models.CertificateResponse
-	resp, err := c.client.R().
-		SetResult(&cert).
-		SetHeader("Content-Type", ApplicationJson).
-		ExpectContentType(ApplicationJson).
-		SetBody(map[string]interface{}{"id": id}).
-		Post(BaseURL + "/api/Certificate/GetCertificate")
+	resp, err := c.do(ctx, request{
+		path: GetCertificatePath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetResult(&cert).
+				SetHeader("Content-Type", ApplicationJson).
+				ExpectContentType(ApplicationJson).
+				SetBody(map[string]interface{}{"id": id})
+		},
+		idempotent: true,
+	})
 	if err != nil {
 		return nil, err
 	}
-	if !strings.Contains(resp.Header().Get("Content-Type"), ApplicationJson) {
-		return nil, &UnexpectedResponseContentTypeError{ContentType: resp.Header().Get("Content-Type")}
+	if err := checkContentType(resp); err != nil {
+		return nil, err
 	}
 	return &cert, nil
 }
 
-func (c *Client) CheckDomainNames(domains []string) ([]models.DomainResponse, error) {
+func (c *Client) CheckDomainNames(ctx context.Context, domains []string) (_ []models.DomainResponse, err error) {
+	ctx, span := c.startSpan(ctx, "CheckDomainNames")
+	defer endSpan(span, &err)
+
 	domainDto := make([]Domain, 0)
 	for _, domain := range domains {
 		domainDto = append(domainDto, Domain{Domain: domain})
 	}
 	domainResp := make([]models.DomainResponse, 0)
-	resp, err := c.client.R().
-		SetResult(&domainResp).
-		SetHeader("Content-Type", ApplicationJson).
-		ExpectContentType(ApplicationJson).
-		SetBody(domainDto).
-		Post(BaseURL + "/api/ServerCertificate/CheckDomainNames")
+	resp, err := c.do(ctx, request{
+		path: CheckDomainNamesPath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetResult(&domainResp).
+				SetHeader("Content-Type", ApplicationJson).
+				ExpectContentType(ApplicationJson).
+				SetBody(domainDto)
+		},
+		idempotent: true,
+	})
 	if err != nil {
 		return nil, err
 	}
-	if !strings.Contains(resp.Header().Get("Content-Type"), ApplicationJson) {
-		return nil, &UnexpectedResponseContentTypeError{ContentType: resp.Header().Get("Content-Type")}
+	if err := checkContentType(resp); err != nil {
+		return nil, err
 	}
 	return domainResp, nil
 }
 
-func (c *Client) RequestCertificate(domains []models.DomainResponse, csr string, transactionType string) (*models.CertificateRequestResponse, error) {
+func (c *Client) RequestCertificate(ctx context.Context, domains []models.DomainResponse, csr string, transactionType string) (_ *models.CertificateRequestResponse, err error) {
+	ctx, span := c.startSpan(ctx, "RequestCertificate")
+	defer endSpan(span, &err)
+
+	result, err := c.requestCertificate(ctx, domains, csr, transactionType, nil)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attrTransactionID.String(result.TransactionID))
+	return result, nil
+}
+
+// requestCertificate submits a certificate request. The fields in extra are
+// added to the form.
+func (c *Client) requestCertificate(ctx context.Context, domains []models.DomainResponse, csr string, transactionType string, extra map[string]string) (*models.CertificateRequestResponse, error) {
 	domainJsonBytes, _ := json.Marshal(domains)
 	domainJson := string(domainJsonBytes)
+	form := map[string]string{
+		"domains":         domainJson,
+		"domainsString":   domainJson,
+		"csr":             csr,
+		"isManualCsr":     "true",
+		"consentSameKey":  "true",
+		"transactionType": transactionType,
+		"duration":        "1",
+	}
+	for key, value := range extra {
+		form[key] = value
+	}
 	var result models.CertificateRequestResponse
-	resp, err := c.client.R().
-		SetHeader("Content-Type", "multipart/form-data").
-		SetResult(&result).
-		ExpectContentType(ApplicationJson).
-		SetMultipartFormData(map[string]string{
-			"domains":         domainJson,
-			"domainsString":   domainJson,
-			"csr":             csr,
-			"isManualCsr":     "true",
-			"consentSameKey":  "true",
-			"transactionType": transactionType,
-			"duration":        "1",
-		}).
-		Post(BaseURL + "/api/ServerCertificate/RequestServerCertificate")
+	resp, err := c.do(ctx, request{
+		path: RequestCertPath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetHeader("Content-Type", "multipart/form-data").
+				SetResult(&result).
+				ExpectContentType(ApplicationJson).
+				SetMultipartFormData(form)
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
-	if !strings.Contains(resp.Header().Get("Content-Type"), ApplicationJson) {
-		return nil, &UnexpectedResponseContentTypeError{ContentType: resp.Header().Get("Content-Type")}
+	if err := checkContentType(resp); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
 
-func (c *Client) GetPendingReviews() ([]models.ReviewResponse, error) {
+// GetPendingReviews returns the first page of pending reviewable
+// transactions. ListPendingReviews and FindPendingReview also reach later
+// pages.
+func (c *Client) GetPendingReviews(ctx context.Context) (_ []models.ReviewResponse, err error) {
+	ctx, span := c.startSpan(ctx, "GetPendingReviews")
+	defer endSpan(span, &err)
+
+	page, err := c.pendingReviews(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// ListPendingReviews returns the page of pending reviewable transactions
+// starting at startIndex.
+func (c *Client) ListPendingReviews(ctx context.Context, startIndex int) (_ *Page[models.ReviewResponse], err error) {
+	ctx, span := c.startSpan(ctx, "ListPendingReviews")
+	defer endSpan(span, &err)
+
+	return c.pendingReviews(ctx, startIndex)
+}
+
+// FindPendingReview pages through the pending reviewable transactions and
+// returns the one of the transaction id. It returns ErrNotFound if there is
+// none.
+func (c *Client) FindPendingReview(ctx context.Context, id string) (_ *models.ReviewResponse, err error) {
+	ctx = withTransactionID(ctx, id)
+	ctx, span := c.startSpan(ctx, "FindPendingReview", attrTransactionID.String(id))
+	defer endSpan(span, &err)
+
+	var found *models.ReviewResponse
+	err = eachPage(func(index int) (*Page[models.ReviewResponse], error) {
+		return c.pendingReviews(ctx, index)
+	}, func(page *Page[models.ReviewResponse]) bool {
+		for _, r := range page.Items {
+			if r.TransactionID == id {
+				found = &r
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: no pending review for transaction %s", ErrNotFound, id)
+	}
+	return found, nil
+}
+
+// pendingReviews returns the page of pending reviews starting at startIndex.
+func (c *Client) pendingReviews(ctx context.Context, startIndex int) (*Page[models.ReviewResponse], error) {
 	var pending []models.ReviewResponse
-	resp, err := c.client.R().
-		SetResult(&pending).
-		SetHeader("Content-Type", ApplicationJson).
-		ExpectContentType(ApplicationJson).
-		SetBody(models.ReviewRequest{
-			StartIndex:     0,
-			Status:         "Pending",
-			FilterPostDTOs: []any{},
-		}).
-		Post(BaseURL + "/api/OrganizationValidatorSSL/GetSSLReviewableTransactions")
+	resp, err := c.do(ctx, request{
+		path: ReviewablePath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetResult(&pending).
+				SetHeader("Content-Type", ApplicationJson).
+				ExpectContentType(ApplicationJson).
+				SetBody(models.ReviewRequest{
+					StartIndex:     startIndex,
+					Status:         StatusPending,
+					FilterPostDTOs: []any{},
+				})
+		},
+		idempotent: true,
+	})
 	if err != nil {
 		return nil, err
 	}
-	if !strings.Contains(resp.Header().Get("Content-Type"), ApplicationJson) {
-		return nil, &UnexpectedResponseContentTypeError{ContentType: resp.Header().Get("Content-Type")}
+	if err := checkContentType(resp); err != nil {
+		return nil, err
+	}
+	page := &Page[models.ReviewResponse]{Items: pending}
+	if len(pending) > 0 {
+		page.Next = startIndex + len(pending)
+	}
+	for _, p := range pending {
+		page.fetched = append(page.fetched, p.TransactionID)
 	}
-	return pending, nil
+	page.ids = page.fetched
+	return page, nil
 }
 
-func (c *Client) ApproveRequest(id, message, value string) error {
-	_, err := c.client.R().
-		SetHeader("Content-Type", "multipart/form-data").
-		SetMultipartFormData(map[string]string{
-			"reviewId":        id,
-			"isValid":         "true",
-			"informApplicant": "true",
-			"reviewMessage":   message,
-			"reviewValue":     value,
-		}).
-		Post(BaseURL + "/api/OrganizationValidatorSSL/UpdateReviews")
+func (c *Client) ApproveRequest(ctx context.Context, id, message, value string) (err error) {
+	ctx, span := c.startSpan(ctx, "ApproveRequest", attrReviewID.String(id))
+	defer endSpan(span, &err)
+
+	_, err = c.do(ctx, request{
+		path: UpdateReviewsPath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetHeader("Content-Type", "multipart/form-data").
+				SetMultipartFormData(map[string]string{
+					"reviewId":        id,
+					"isValid":         "true",
+					"informApplicant": "true",
+					"reviewMessage":   message,
+					"reviewValue":     value,
+				})
+		},
+		applied: func(ctx context.Context) (bool, error) {
+			return c.isReviewed(ctx, id)
+		},
+	})
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (c *Client) Shutdown() error {
-	err := c.scheduler.Shutdown()
+// isReviewed reports whether the review with the given ID is marked as
+// reviewed, i.e. a previous ApproveRequest went through. As the transaction
+// of a review leaves the pending list once all of its reviews are done, a
+// review that is not found cannot be told apart from an unknown one and
+// results in an error.
+func (c *Client) isReviewed(ctx context.Context, reviewID string) (bool, error) {
+	var reviewed, found bool
+	err := eachPage(func(index int) (*Page[models.ReviewResponse], error) {
+		return c.pendingReviews(ctx, index)
+	}, func(page *Page[models.ReviewResponse]) bool {
+		for _, p := range page.Items {
+			for _, r := range p.ReviewGetDTOs {
+				if r.ReviewID == reviewID {
+					reviewed, found = r.IsReviewed, true
+					return false
+				}
+			}
+		}
+		return true
+	})
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	if !found {
+		return false, fmt.Errorf("%w: review %s is not pending", ErrNotFound, reviewID)
+	}
+	return reviewed, nil
+}
+
+// RevokeCertificate revokes the certificate issued for the transaction id.
+// The comment is stored with the revocation in HARICA.
+func (c *Client) RevokeCertificate(ctx context.Context, id string, reason RevocationReasonName, comment string) (err error) {
+	ctx = withTransactionID(ctx, id)
+	ctx, span := c.startSpan(ctx, "RevokeCertificate", attrTransactionID.String(id))
+	defer endSpan(span, &err)
+
+	_, err = c.do(ctx, request{
+		path: RevokeCertificatePath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetHeader("Content-Type", ApplicationJson).
+				SetBody(models.RevocationRequest{
+					TransactionID: id,
+					Name:          string(reason),
+					Notes:         comment,
+				})
+		},
+		applied: func(ctx context.Context) (bool, error) {
+			cert, err := c.GetCertificate(ctx, id)
+			if err != nil {
+				return false, err
+			}
+			return cert.IsRevoked, nil
+		},
+	})
+	return err
+}
+
+// CancelTransaction withdraws the pending certificate request id. Issued,
+// rejected or already cancelled transactions cannot be cancelled.
+func (c *Client) CancelTransaction(ctx context.Context, id string) (err error) {
+	ctx = withTransactionID(ctx, id)
+	ctx, span := c.startSpan(ctx, "CancelTransaction", attrTransactionID.String(id))
+	defer endSpan(span, &err)
+
+	_, err = c.do(ctx, request{
+		path: CancelTransactionPath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetHeader("Content-Type", ApplicationJson).
+				SetBody(map[string]interface{}{"id": id})
+		},
+		applied: func(ctx context.Context) (bool, error) {
+			t, err := c.GetTransaction(ctx, id)
+			if err != nil {
+				return false, err
+			}
+			return t.TransactionStatus == StatusCancelled, nil
+		},
+	})
+	return err
 }
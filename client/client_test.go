@@ -0,0 +1,218 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/hm-edu/harica/fake"
+	"github.com/hm-edu/harica/models"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	requesterEmail = "requester@example.org"
+	validatorEmail = "validator@example.org"
+	adminEmail     = "admin@example.org"
+	password       = "secret"
+	totpSeed       = "JBSWY3DPEHPK3PXP"
+)
+
+// newPortal starts a fake portal with a requester, a validator and an
+// enterprise admin account.
+func newPortal(t *testing.T) *fake.Server {
+	t.Helper()
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddUser(fake.User{Email: requesterEmail, Password: password, Roles: []fake.Role{fake.RoleRequester}})
+	srv.AddUser(fake.User{Email: validatorEmail, Password: password, Roles: []fake.Role{fake.RoleValidator}})
+	srv.AddUser(fake.User{Email: adminEmail, Password: password, Roles: []fake.Role{fake.RoleRequester, fake.RoleEnterpriseAdmin}})
+	return srv
+}
+
+// newClient logs in to srv as email. The client is closed at the end of the
+// test.
+func newClient(t *testing.T, srv *fake.Server, email string, options ...client.Option) *client.Client {
+	t.Helper()
+	options = append([]client.Option{client.WithBaseURL(srv.URL), client.WithBackgroundRefresh(false)}, options...)
+	c, err := client.NewClient(email, password, "", options...)
+	if err != nil {
+		t.Fatalf("login as %s: %v", email, err)
+	}
+	t.Cleanup(func() { c.Close(context.Background()) }) //nolint:errcheck
+	return c
+}
+
+// newCSR returns a certificate request for domains with a fresh key.
+func newCSR(t *testing.T, domains ...string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := client.NewCSR(key, domains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+// request submits a certificate request for domains and returns its
+// transaction ID.
+func request(t *testing.T, c *client.Client, domains ...string) string {
+	t.Helper()
+	ctx := context.Background()
+	checked, err := c.CheckDomainNames(ctx, domains)
+	if err != nil {
+		t.Fatalf("CheckDomainNames: %v", err)
+	}
+	resp, err := c.RequestCertificate(ctx, checked, newCSR(t, domains...), "DV")
+	if err != nil {
+		t.Fatalf("RequestCertificate: %v", err)
+	}
+	return resp.TransactionID
+}
+
+// approve approves all reviews of the transaction id.
+func approve(t *testing.T, v *client.Client, id string) {
+	t.Helper()
+	ctx := context.Background()
+	review, err := v.FindPendingReview(ctx, id)
+	if err != nil {
+		t.Fatalf("FindPendingReview: %v", err)
+	}
+	for _, dto := range review.ReviewGetDTOs {
+		if err := v.ApproveRequest(ctx, dto.ReviewID, "Auto Approval", dto.ReviewValue); err != nil {
+			t.Fatalf("ApproveRequest: %v", err)
+		}
+	}
+}
+
+// issue requests and approves a certificate for domains and returns its
+// transaction ID.
+func issue(t *testing.T, requester, validator *client.Client, domains ...string) string {
+	t.Helper()
+	id := request(t, requester, domains...)
+	approve(t, validator, id)
+	return id
+}
+
+func TestLogin(t *testing.T) {
+	srv := newPortal(t)
+	c := newClient(t, srv, requesterEmail)
+	info, err := c.Session()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Claims.Email != requesterEmail || !info.Capabilities.RequestCertificates {
+		t.Errorf("Session() = %+v", info)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("Logins() = %d, want 1", srv.Logins())
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	srv := newPortal(t)
+	_, err := client.NewClient(requesterEmail, "wrong", "", client.WithBaseURL(srv.URL), client.WithBackgroundRefresh(false))
+	if !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("NewClient = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestLoginTOTP(t *testing.T) {
+	srv := newPortal(t)
+	srv.AddUser(fake.User{Email: "totp@example.org", Password: password, TOTPSeed: totpSeed, Roles: []fake.Role{fake.RoleRequester}})
+
+	c, err := client.NewClient("totp@example.org", password, totpSeed, client.WithBaseURL(srv.URL), client.WithBackgroundRefresh(false))
+	if err != nil {
+		t.Fatalf("login with seed: %v", err)
+	}
+	c.Close(context.Background()) //nolint:errcheck
+
+	code := client.OTPFunc(func(context.Context) (string, error) {
+		return totp.GenerateCode(totpSeed, time.Now())
+	})
+	c, err = client.NewClient("totp@example.org", password, "", client.WithBaseURL(srv.URL), client.WithBackgroundRefresh(false), client.WithOTPProvider(code))
+	if err != nil {
+		t.Fatalf("login with OTP provider: %v", err)
+	}
+	c.Close(context.Background()) //nolint:errcheck
+
+	_, err = client.NewClient("totp@example.org", password, "", client.WithBaseURL(srv.URL), client.WithBackgroundRefresh(false))
+	if !errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("login without code = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestGenCert(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail)
+	ctx := context.Background()
+
+	id := issue(t, requester, validator, "www.example.org", "example.org")
+	cert, err := requester.GetCertificate(ctx, id)
+	if err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	if cert.PemBundle == "" || cert.SANS != "www.example.org,example.org" {
+		t.Errorf("GetCertificate = %+v", cert)
+	}
+	if tr, _ := srv.Transaction(id); tr.Status != fake.StatusCompleted {
+		t.Errorf("status = %s, want %s", tr.Status, fake.StatusCompleted)
+	}
+}
+
+func TestReloginOnceAfterExpiredSessions(t *testing.T) {
+	srv := newPortal(t)
+	c := newClient(t, srv, requesterEmail)
+	srv.ExpireSessions()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.CheckDomainNames(context.Background(), []string{"example.org"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("CheckDomainNames: %v", err)
+		}
+	}
+	if srv.Logins() != 2 {
+		t.Errorf("Logins() = %d, want 2", srv.Logins())
+	}
+}
+
+func TestForbiddenDoesNotLogIn(t *testing.T) {
+	srv := newPortal(t)
+	validator := newClient(t, srv, validatorEmail)
+	ctx := context.Background()
+	csr := newCSR(t, "example.org")
+
+	for range 3 {
+		_, err := validator.RequestCertificate(ctx, []models.DomainResponse{{Domain: "example.org"}}, csr, "DV")
+		if !errors.Is(err, client.ErrForbidden) || errors.Is(err, client.ErrUnauthorized) {
+			t.Fatalf("RequestCertificate = %v, want ErrForbidden only", err)
+		}
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("Logins() = %d, want 1", srv.Logins())
+	}
+	if n := len(srv.Transactions()); n != 0 {
+		t.Errorf("%d transactions, want 0", n)
+	}
+}
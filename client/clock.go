@@ -0,0 +1,13 @@
+package client
+
+import "github.com/jonboulle/clockwork"
+
+// WithClock replaces the clock used for token expiry, one-time codes,
+// retry backoff, Retry-After dates, the refresh schedule, refresh timestamps
+// and request durations. It defaults to the system clock; tests can pass a
+// clockwork.FakeClock to control time.
+func WithClock(clock clockwork.Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
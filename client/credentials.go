@@ -0,0 +1,175 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// ErrMissingCredentials is returned if a credential provider does not supply
+// an email address or password.
+var ErrMissingCredentials = errors.New("harica: missing email or password")
+
+// Credentials identify a HARICA account. TOTPSeed is optional and only
+// required for accounts with two-factor authentication.
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	TOTPSeed string `json:"totpSeed,omitempty"`
+}
+
+// CredentialProvider supplies the credentials for a login. It is consulted
+// for every login, so rotated secrets are picked up without a restart.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// StaticCredentials always returns the same credentials.
+type StaticCredentials Credentials
+
+func (s StaticCredentials) Credentials(context.Context) (Credentials, error) {
+	return Credentials(s), nil
+}
+
+// EnvCredentials reads the credentials from the environment variables
+// <Prefix>_EMAIL, <Prefix>_PASSWORD and <Prefix>_TOTP_SEED.
+type EnvCredentials struct {
+	Prefix string
+}
+
+func (e EnvCredentials) Credentials(context.Context) (Credentials, error) {
+	return Credentials{
+		Email:    os.Getenv(e.Prefix + "_EMAIL"),
+		Password: os.Getenv(e.Prefix + "_PASSWORD"),
+		TOTPSeed: os.Getenv(e.Prefix + "_TOTP_SEED"),
+	}, nil
+}
+
+// FileCredentials reads each secret from its own file, as used for Docker
+// and Kubernetes secrets. Empty paths are skipped, as is a missing
+// TOTPSeedFile. Surrounding whitespace is removed from the file contents.
+type FileCredentials struct {
+	EmailFile    string
+	PasswordFile string
+	TOTPSeedFile string
+}
+
+// NewDirCredentials reads the files email, password and totp-seed from dir.
+func NewDirCredentials(dir string) FileCredentials {
+	return FileCredentials{
+		EmailFile:    filepath.Join(dir, "email"),
+		PasswordFile: filepath.Join(dir, "password"),
+		TOTPSeedFile: filepath.Join(dir, "totp-seed"),
+	}
+}
+
+func (f FileCredentials) Credentials(context.Context) (Credentials, error) {
+	var creds Credentials
+	var err error
+	if creds.Email, err = readSecretFile(f.EmailFile, false); err != nil {
+		return Credentials{}, err
+	}
+	if creds.Password, err = readSecretFile(f.PasswordFile, false); err != nil {
+		return Credentials{}, err
+	}
+	if creds.TOTPSeed, err = readSecretFile(f.TOTPSeedFile, true); err != nil {
+		return Credentials{}, err
+	}
+	return creds, nil
+}
+
+func readSecretFile(path string, optional bool) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if optional && errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// CommandCredentials runs an external command, e.g. `pass show harica`, and
+// uses the first line of its output as password. Following the pass
+// convention for additional fields, a line "totp-seed: <seed>" provides the
+// TOTP seed.
+type CommandCredentials struct {
+	Email   string
+	Command []string
+}
+
+func (c CommandCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	if len(c.Command) == 0 {
+		return Credentials{}, errors.New("harica: no credential command given")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command[0], c.Command[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return Credentials{}, fmt.Errorf("running %s: %w: %s", c.Command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	creds := Credentials{Email: c.Email}
+	for i, line := range strings.Split(string(out), "\n") {
+		if i == 0 {
+			creds.Password = strings.TrimSpace(line)
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == "totp-seed" {
+			creds.TOTPSeed = strings.TrimSpace(value)
+		}
+	}
+	return creds, nil
+}
+
+// EncryptedFileCredentials reads the credentials as JSON object with the
+// keys email, password and totpSeed from a file encrypted with age using a
+// passphrase (`age --passphrase`, optionally with --armor).
+type EncryptedFileCredentials struct {
+	Path       string
+	Passphrase string
+}
+
+func (e EncryptedFileCredentials) Credentials(context.Context) (Credentials, error) {
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	defer f.Close() //nolint:errcheck
+	identity, err := age.NewScryptIdentity(e.Passphrase)
+	if err != nil {
+		return Credentials{}, err
+	}
+	br := bufio.NewReader(f)
+	var in io.Reader = br
+	if start, _ := br.Peek(len(armor.Header)); string(start) == armor.Header {
+		in = armor.NewReader(br)
+	}
+	r, err := age.Decrypt(in, identity)
+	if err != nil {
+		return Credentials{}, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Credentials{}, err
+	}
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return Credentials{}, err
+	}
+	return creds, nil
+}
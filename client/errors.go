@@ -0,0 +1,172 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+var (
+	// ErrUnauthorized is matched by API errors caused by rejected credentials,
+	// an expired session or a stale antiforgery token.
+	ErrUnauthorized = errors.New("harica: unauthorized")
+	// ErrForbidden is matched by API errors where the account lacks the role
+	// for a call. The client does not log in again for these.
+	ErrForbidden = errors.New("harica: forbidden")
+	// ErrNotFound is matched by API errors for unknown resources.
+	ErrNotFound = errors.New("harica: not found")
+	// ErrValidationFailed is matched by API errors where HARICA rejected the
+	// submitted data.
+	ErrValidationFailed = errors.New("harica: validation failed")
+	// ErrRateLimited is matched by API errors caused by too many requests.
+	ErrRateLimited = errors.New("harica: rate limited")
+	// ErrVerificationTokenNotFound is returned if the portal page does not
+	// contain a __RequestVerificationToken.
+	ErrVerificationTokenNotFound = errors.New("harica: verification token not found")
+	// ErrClosed is returned by calls on a client after Close.
+	ErrClosed = errors.New("harica: client closed")
+	// ErrCertificateRejected is returned by WaitForCertificate if the request
+	// was rejected during review.
+	ErrCertificateRejected = errors.New("harica: certificate request rejected")
+	// ErrTransactionCancelled is returned by WaitForCertificate if the request
+	// was cancelled.
+	ErrTransactionCancelled = errors.New("harica: certificate request cancelled")
+	// ErrIssuanceTimeout is returned by WaitForCertificate if the certificate
+	// was not issued in time.
+	ErrIssuanceTimeout = errors.New("harica: timed out waiting for certificate")
+)
+
+// maxBodyExcerpt limits how much of an error response is kept in APIError.
+const maxBodyExcerpt = 512
+
+type UnexpectedResponseContentTypeError struct {
+	ContentType string
+}
+
+func (e *UnexpectedResponseContentTypeError) Error() string {
+	return fmt.Sprintf("unexpected response content type: %s", e.ContentType)
+}
+
+// APIError describes a non-successful response of the HARICA API.
+type APIError struct {
+	StatusCode int
+	Endpoint   string
+	// Message is the error message reported by HARICA, if any could be
+	// extracted from the response.
+	Message string
+	// Body is a truncated excerpt of the raw response body.
+	Body string
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("harica: %s returned %d: %s", e.Endpoint, e.StatusCode, msg)
+}
+
+// IsAuthFailure reports whether the error was caused by missing or rejected
+// authentication, including a failed login. A 403 outside of the login
+// endpoints means the account lacks a role and is no auth failure.
+func (e *APIError) IsAuthFailure() bool {
+	if e.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	// HARICA does not reliably use 401 for rejected logins, so any non-server
+	// error of the login endpoints counts as an authentication failure, except
+	// for rate limiting.
+	if e.StatusCode < 500 && e.StatusCode != http.StatusTooManyRequests && isLoginPath(e.Endpoint) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(e.Body), "antiforgery")
+}
+
+// Is allows matching an APIError against ErrUnauthorized, ErrForbidden,
+// ErrNotFound, ErrRateLimited and ErrValidationFailed using errors.Is.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.IsAuthFailure()
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden && !e.IsAuthFailure()
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrValidationFailed:
+		return !e.IsAuthFailure() &&
+			(e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity)
+	}
+	return false
+}
+
+func isLoginPath(path string) bool {
+	return path == LoginPath || path == LoginPathTotp
+}
+
+// newAPIError converts resp into an *APIError. A Retry-After date is
+// converted relative to now.
+func newAPIError(endpoint string, resp *resty.Response, now time.Time) *APIError {
+	body := resp.String()
+	excerpt := body
+	if len(excerpt) > maxBodyExcerpt {
+		excerpt = excerpt[:maxBodyExcerpt]
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode(),
+		Endpoint:   endpoint,
+		Message:    extractMessage(body),
+		Body:       excerpt,
+		RetryAfter: parseRetryAfter(resp.Header().Get("Retry-After"), now),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// HTTP date, which is taken relative to now.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// extractMessage tries to find a human readable message in an error body.
+// HARICA responds with plain strings, JSON strings or problem details.
+func extractMessage(body string) string {
+	body = strings.TrimSpace(body)
+	if body == "" || strings.HasPrefix(body, "<") {
+		return ""
+	}
+	var str string
+	if err := json.Unmarshal([]byte(body), &str); err == nil {
+		return str
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(body), &obj); err == nil {
+		for _, key := range []string{"message", "Message", "detail", "title", "error"} {
+			if v, ok := obj[key].(string); ok && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	if len(body) > maxBodyExcerpt {
+		body = body[:maxBodyExcerpt]
+	}
+	return body
+}
@@ -1,19 +1,24 @@
 package client
 
 import (
+	"context"
 	"strings"
 
 	"github.com/go-resty/resty/v2"
 	"golang.org/x/net/html"
 )
 
-func getVerificationToken(r *resty.Client) (string, error) {
+func (c *Client) getVerificationToken(ctx context.Context, r *resty.Client) (string, error) {
 	resp, err := r.
 		R().
-		Get(BaseURL)
+		SetContext(ctx).
+		Get("/")
 	if err != nil {
 		return "", err
 	}
+	if err := c.checkResponse("/", resp); err != nil {
+		return "", err
+	}
 	doc, err := html.Parse(strings.NewReader(resp.String()))
 	if err != nil {
 		return "", err
@@ -48,5 +53,24 @@ func getVerificationToken(r *resty.Client) (string, error) {
 	}
 
 	processHtml(doc)
+	if verificationToken == "" {
+		return "", ErrVerificationTokenNotFound
+	}
 	return verificationToken, nil
 }
+
+// checkResponse converts an unsuccessful response into an *APIError.
+func (c *Client) checkResponse(endpoint string, resp *resty.Response) error {
+	if resp.IsError() {
+		return newAPIError(endpoint, resp, c.clock.Now())
+	}
+	return nil
+}
+
+// checkContentType ensures a successful response carries a JSON body.
+func checkContentType(resp *resty.Response) error {
+	if !strings.Contains(resp.Header().Get("Content-Type"), ApplicationJson) {
+		return &UnexpectedResponseContentTypeError{ContentType: resp.Header().Get("Content-Type")}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+
+	"github.com/hm-edu/harica/models"
+)
+
+// Requester requests certificates on behalf of an account with the User
+// role.
+type Requester interface {
+	CheckDomainNames(ctx context.Context, domains []string) ([]models.DomainResponse, error)
+	CheckMatchingOrganization(ctx context.Context, domains []string) ([]models.OrganizationResponse, error)
+	RequestCertificate(ctx context.Context, domains []models.DomainResponse, csr string, transactionType string) (*models.CertificateRequestResponse, error)
+}
+
+// Validator reviews pending certificate requests on behalf of an account
+// with the SSL Enterprise Approver role.
+type Validator interface {
+	GetPendingReviews(ctx context.Context) ([]models.ReviewResponse, error)
+	ApproveRequest(ctx context.Context, id, message, value string) error
+}
+
+// CertificateReader retrieves issued certificates.
+type CertificateReader interface {
+	GetCertificate(ctx context.Context, id string) (*models.CertificateResponse, error)
+}
+
+// Waiter waits for requested certificates to be issued.
+type Waiter interface {
+	WaitForCertificate(ctx context.Context, id string) (*models.CertificateResponse, error)
+}
+
+// Canceller withdraws pending certificate requests.
+type Canceller interface {
+	CancelTransaction(ctx context.Context, id string) error
+}
+
+// Renewer requests successors of issued certificates.
+type Renewer interface {
+	RenewCertificate(ctx context.Context, id string, req RenewalRequest) (*Renewal, error)
+}
+
+// Revoker revokes issued certificates.
+type Revoker interface {
+	RevokeCertificate(ctx context.Context, id string, reason RevocationReasonName, comment string) error
+}
+
+// Lister enumerates the transactions and certificates of an account.
+type Lister interface {
+	ListTransactions(ctx context.Context, filter TransactionFilter, startIndex int) (*Page[models.TransactionResponse], error)
+	ListCertificates(ctx context.Context, filter TransactionFilter, startIndex int) (*Page[models.CertificateSummary], error)
+}
+
+var (
+	_ Requester         = (*Client)(nil)
+	_ Validator         = (*Client)(nil)
+	_ CertificateReader = (*Client)(nil)
+	_ Waiter            = (*Client)(nil)
+	_ Canceller         = (*Client)(nil)
+	_ Renewer           = (*Client)(nil)
+	_ Revoker           = (*Client)(nil)
+	_ Lister            = (*Client)(nil)
+)
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Status describes the state of the session of a Client.
+type Status struct {
+	// Account is the email of the logged in account.
+	Account string
+	// TokenExpiry is the expiry of the current session token.
+	TokenExpiry time.Time
+	// LastRefresh is the time of the last attempt to refresh the session.
+	LastRefresh time.Time
+	// LastSuccessfulRefresh is the time the session was last refreshed or
+	// established successfully.
+	LastSuccessfulRefresh time.Time
+	// LastError is the error of the last refresh, or nil if it succeeded.
+	LastError error
+	// Closed reports whether Close was called.
+	Closed bool
+
+	// at is the time the status was taken.
+	at time.Time
+}
+
+// Healthy reports whether the client holds a valid session and the last
+// refresh succeeded.
+func (s Status) Healthy() bool {
+	return !s.Closed && s.LastError == nil && s.at.Before(s.TokenExpiry)
+}
+
+// WithBackgroundRefresh controls whether the client renews its session every
+// RefreshInterval in the background, which is the default. Without it, the
+// session is renewed before a request if the token is about to expire, and
+// no goroutines outlive the calls of the client.
+func WithBackgroundRefresh(enabled bool) Option {
+	return func(c *Client) {
+		c.backgroundRefresh = enabled
+	}
+}
+
+// Status returns the current state of the session, including the error of
+// the last background or lazy refresh.
+func (c *Client) Status() Status {
+	c.mu.RLock()
+	status := Status{
+		Account:               c.account,
+		LastRefresh:           c.lastRefresh,
+		LastSuccessfulRefresh: c.lastSuccessfulRefresh,
+		LastError:             c.lastRefreshErr,
+		Closed:                c.closed,
+		at:                    c.clock.Now(),
+	}
+	token := c.currentToken
+	c.mu.RUnlock()
+	if exp, err := tokenExpiry(token); err == nil {
+		status.TokenExpiry = exp
+	}
+	return status
+}
+
+// refresh renews the session if necessary and records the outcome.
+func (c *Client) refresh(ctx context.Context) error {
+	err := c.prepareClient(ctx)
+	c.recordRefresh(err)
+	c.metrics.observeRefresh(c.accountName(), err, c.clock.Now())
+	return err
+}
+
+func (c *Client) recordRefresh(err error) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastRefresh = now
+	c.lastRefreshErr = err
+	if err == nil {
+		c.lastSuccessfulRefresh = now
+	}
+}
+
+// refreshIfNeeded renews the session before a request if background refresh
+// is disabled and the token is about to expire.
+func (c *Client) refreshIfNeeded(ctx context.Context) error {
+	if c.backgroundRefresh {
+		return nil
+	}
+	if _, token := c.session(); token != "" {
+		if renew, err := c.needsRenewal(token); err == nil && !renew {
+			return nil
+		}
+	}
+	return c.refresh(ctx)
+}
+
+// isClosed reports whether Close was called.
+func (c *Client) isClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
+// Shutdown stops the background refresh. The session stays valid until it
+// expires; use Close to end it. Further calls do nothing.
+func (c *Client) Shutdown() error {
+	c.mu.Lock()
+	scheduler := c.scheduler
+	c.scheduler = nil
+	c.mu.Unlock()
+	if scheduler == nil {
+		return nil
+	}
+	return scheduler.Shutdown()
+}
+
+// Close stops the background refresh, logs out of the portal and removes the
+// session from the session cache. The client cannot be used afterwards.
+func (c *Client) Close(ctx context.Context) (err error) {
+	ctx, span := c.startSpan(ctx, "Close")
+	defer endSpan(span, &err)
+
+	if err := c.Shutdown(); err != nil {
+		return err
+	}
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	c.mu.Lock()
+	r, account, closed := c.client, c.account, c.closed
+	c.client, c.currentToken, c.closed = nil, "", true
+	c.mu.Unlock()
+	if closed || r == nil {
+		return nil
+	}
+	if c.cache != nil {
+		if err := c.cache.Store(c.cacheKey(account), nil); err != nil {
+			c.logger.Warn("failed to remove session from cache", slog.Any("error", err))
+		}
+	}
+	resp, err := r.R().SetContext(ctx).Post(LogoutPath)
+	if err != nil {
+		return err
+	}
+	return c.checkResponse(LogoutPath, resp)
+}
@@ -0,0 +1,67 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/hm-edu/harica/client"
+)
+
+func TestCloseDuringRequests(t *testing.T) {
+	srv := newPortal(t)
+	for _, background := range []bool{true, false} {
+		for range 20 {
+			c := newClient(t, srv, requesterEmail, client.WithBackgroundRefresh(background))
+			var wg sync.WaitGroup
+			for range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := c.CheckDomainNames(context.Background(), []string{"example.org"})
+					if err != nil && !errors.Is(err, client.ErrClosed) && !errors.Is(err, client.ErrUnauthorized) {
+						t.Errorf("CheckDomainNames: %v", err)
+					}
+				}()
+			}
+			if err := c.Close(context.Background()); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			wg.Wait()
+			if status := c.Status(); !status.Closed || status.Healthy() {
+				t.Errorf("Status() after Close = %+v", status)
+			}
+		}
+	}
+}
+
+func TestClosedClientDoesNotLogIn(t *testing.T) {
+	srv := newPortal(t)
+	c := newClient(t, srv, requesterEmail)
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	logins := srv.Logins()
+	if _, err := c.CheckDomainNames(context.Background(), []string{"example.org"}); !errors.Is(err, client.ErrClosed) {
+		t.Fatalf("CheckDomainNames after Close = %v, want ErrClosed", err)
+	}
+	if _, err := c.Session(); !errors.Is(err, client.ErrClosed) {
+		t.Fatalf("Session after Close = %v, want ErrClosed", err)
+	}
+	if srv.Logins() != logins {
+		t.Errorf("closed client logged in again")
+	}
+	if err := c.Close(context.Background()); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	srv := newPortal(t)
+	c := newClient(t, srv, requesterEmail)
+	status := c.Status()
+	if !status.Healthy() || status.Account != requesterEmail || status.TokenExpiry.IsZero() {
+		t.Errorf("Status() = %+v", status)
+	}
+}
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hm-edu/harica/models"
+)
+
+// TransactionFilter selects transactions in listings. Zero fields match
+// everything. Status is evaluated by HARICA, all other fields by the client.
+type TransactionFilter struct {
+	// Status is a transaction status such as "Pending" or "Completed".
+	Status string
+	// Domain matches transactions with a domain containing it, ignoring case.
+	Domain string
+	// TransactionType is e.g. "DV" or "OV".
+	TransactionType string
+	// IssuedAfter and IssuedBefore bound the issuance of the certificate.
+	IssuedAfter  time.Time
+	IssuedBefore time.Time
+	// ExpiresAfter and ExpiresBefore bound the expiry of the certificate.
+	ExpiresAfter  time.Time
+	ExpiresBefore time.Time
+	// Revoked selects revoked or not revoked certificates if set.
+	Revoked *bool
+}
+
+// Page is one batch of a listing. Next is the start index of the following
+// page, or 0 if there are no more pages. As most filters are applied by the
+// client, a page may hold fewer items than HARICA returned, or none at all.
+type Page[T any] struct {
+	Items []T
+	Next  int
+
+	// ids holds the transaction IDs of Items, fetched those of all
+	// transactions HARICA returned for the page, before filtering.
+	ids     []string
+	fetched []string
+}
+
+// maxPages bounds the number of pages read by a single listing, in case
+// HARICA keeps returning pages.
+const maxPages = 1000
+
+// matches reports whether t is selected by f.
+func (f TransactionFilter) matches(t models.TransactionResponse) bool {
+	if f.TransactionType != "" && !strings.EqualFold(t.TransactionType, f.TransactionType) {
+		return false
+	}
+	if f.Domain != "" && !containsDomain(t.Domains, f.Domain) {
+		return false
+	}
+	if f.Revoked != nil && t.IsRevoked != *f.Revoked {
+		return false
+	}
+	if !inRange(t.IssuedAt, f.IssuedAfter, f.IssuedBefore) {
+		return false
+	}
+	return inRange(t.CertificateValidTo, f.ExpiresAfter, f.ExpiresBefore)
+}
+
+func containsDomain(domains []models.Domains, substr string) bool {
+	substr = strings.ToLower(substr)
+	for _, d := range domains {
+		if strings.Contains(strings.ToLower(d.Fqdn), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// inRange reports whether the RFC 3339 timestamp value lies between after
+// and before. Unset bounds are ignored; a missing or invalid value only
+// matches if both are unset.
+func inRange(value string, after, before time.Time) bool {
+	if after.IsZero() && before.IsZero() {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	return (after.IsZero() || t.After(after)) && (before.IsZero() || t.Before(before))
+}
+
+// ListTransactions returns the page of certificate transactions of the
+// account starting at startIndex that match filter.
+func (c *Client) ListTransactions(ctx context.Context, filter TransactionFilter, startIndex int) (_ *Page[models.TransactionResponse], err error) {
+	ctx, span := c.startSpan(ctx, "ListTransactions")
+	defer endSpan(span, &err)
+
+	var batch []models.TransactionResponse
+	resp, err := c.do(ctx, request{
+		path: MyTransactionsPath,
+		build: func(r *resty.Request) *resty.Request {
+			return r.
+				SetResult(&batch).
+				SetHeader("Content-Type", ApplicationJson).
+				ExpectContentType(ApplicationJson).
+				SetBody(models.TransactionsRequest{
+					StartIndex:     startIndex,
+					Status:         filter.Status,
+					FilterPostDTOs: []any{},
+				})
+		},
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkContentType(resp); err != nil {
+		return nil, err
+	}
+	page := &Page[models.TransactionResponse]{}
+	if len(batch) > 0 {
+		page.Next = startIndex + len(batch)
+	}
+	for _, t := range batch {
+		page.fetched = append(page.fetched, t.TransactionID)
+		if filter.matches(t) {
+			page.Items = append(page.Items, t)
+			page.ids = append(page.ids, t.TransactionID)
+		}
+	}
+	return page, nil
+}
+
+// AllTransactions pages through all transactions that match filter.
+func (c *Client) AllTransactions(ctx context.Context, filter TransactionFilter) ([]models.TransactionResponse, error) {
+	return collect(func(index int) (*Page[models.TransactionResponse], error) {
+		return c.ListTransactions(ctx, filter, index)
+	})
+}
+
+// ListCertificates is like ListTransactions but only returns transactions
+// with an issued certificate.
+func (c *Client) ListCertificates(ctx context.Context, filter TransactionFilter, startIndex int) (*Page[models.CertificateSummary], error) {
+	transactions, err := c.ListTransactions(ctx, filter, startIndex)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page[models.CertificateSummary]{Next: transactions.Next, fetched: transactions.fetched}
+	for _, t := range transactions.Items {
+		if t.Serial == "" {
+			continue
+		}
+		summary := models.CertificateSummary{
+			TransactionID:   t.TransactionID,
+			TransactionType: t.TransactionType,
+			Serial:          t.Serial,
+			IssuedAt:        t.IssuedAt,
+			ValidTo:         t.CertificateValidTo,
+			IsRevoked:       t.IsRevoked,
+			RevokedAt:       t.RevokedAt,
+		}
+		for _, d := range t.Domains {
+			summary.Domains = append(summary.Domains, d.Fqdn)
+		}
+		page.Items = append(page.Items, summary)
+		page.ids = append(page.ids, t.TransactionID)
+	}
+	return page, nil
+}
+
+// AllCertificates pages through all certificates that match filter.
+func (c *Client) AllCertificates(ctx context.Context, filter TransactionFilter) ([]models.CertificateSummary, error) {
+	return collect(func(index int) (*Page[models.CertificateSummary], error) {
+		return c.ListCertificates(ctx, filter, index)
+	})
+}
+
+// collect concatenates the items of all pages returned by list.
+func collect[T any](list func(index int) (*Page[T], error)) ([]T, error) {
+	var items []T
+	err := eachPage(list, func(page *Page[T]) bool {
+		items = append(items, page.Items...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// eachPage calls fn with the pages returned by list, starting at index 0,
+// until fn returns false or there are no more pages. Transactions created
+// while paging shift the most-recent-first listing, so items of earlier pages
+// are dropped from later ones. As HARICA may also ignore the start index, the
+// listing ends with a page without new transactions.
+func eachPage[T any](list func(index int) (*Page[T], error), fn func(*Page[T]) bool) error {
+	seen := map[string]bool{}
+	for index, n := 0, 0; n < maxPages; n++ {
+		page, err := list(index)
+		if err != nil {
+			return err
+		}
+		fresh := false
+		for _, id := range page.fetched {
+			if !seen[id] {
+				fresh = true
+			}
+		}
+		if !fresh {
+			return nil
+		}
+		unseen := &Page[T]{Next: page.Next, fetched: page.fetched}
+		for i, item := range page.Items {
+			if !seen[page.ids[i]] {
+				unseen.Items = append(unseen.Items, item)
+				unseen.ids = append(unseen.ids, page.ids[i])
+			}
+		}
+		for _, id := range page.fetched {
+			seen[id] = true
+		}
+		if !fn(unseen) || page.Next == 0 {
+			return nil
+		}
+		index = page.Next
+	}
+	return fmt.Errorf("harica: listing has more than %d pages", maxPages)
+}
+
+// GetTransaction returns the transaction id of the account. It returns
+// ErrNotFound if there is none.
+func (c *Client) GetTransaction(ctx context.Context, id string) (*models.TransactionResponse, error) {
+	return c.findTransaction(ctx, func(t models.TransactionResponse) bool {
+		return t.TransactionID == id
+	}, "no transaction "+id)
+}
+
+// findTransaction pages through the transactions of the account until match
+// returns true. It returns ErrNotFound with the message notFound if no
+// transaction matches.
+func (c *Client) findTransaction(ctx context.Context, match func(models.TransactionResponse) bool, notFound string) (*models.TransactionResponse, error) {
+	var found *models.TransactionResponse
+	err := eachPage(func(index int) (*Page[models.TransactionResponse], error) {
+		return c.ListTransactions(ctx, TransactionFilter{}, index)
+	}, func(page *Page[models.TransactionResponse]) bool {
+		for _, t := range page.Items {
+			if match(t) {
+				found = &t
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, notFound)
+	}
+	return found, nil
+}
+
+// FindCertificateBySerial returns the transaction of the account that issued
+// the certificate with the given hexadecimal serial number. Colons and
+// leading zeros are ignored. It returns ErrNotFound if there is none.
+func (c *Client) FindCertificateBySerial(ctx context.Context, serial string) (*models.TransactionResponse, error) {
+	return c.findTransaction(ctx, func(t models.TransactionResponse) bool {
+		return t.Serial != "" && normalizeSerial(t.Serial) == normalizeSerial(serial)
+	}, "no certificate with serial "+serial)
+}
+
+func normalizeSerial(serial string) string {
+	serial = strings.ToUpper(strings.ReplaceAll(serial, ":", ""))
+	return strings.TrimLeft(serial, "0")
+}
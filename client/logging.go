@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hm-edu/harica/internal/redact"
+	"github.com/jonboulle/clockwork"
+)
+
+// WithLogger sets the logger used for request events, debug dumps and
+// background refresh failures. It defaults to slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+type transactionIDKey struct{}
+
+// withTransactionID attaches the ID of the transaction a request belongs to,
+// so that it can be included in logs.
+func withTransactionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, transactionIDKey{}, id)
+}
+
+func transactionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(transactionIDKey{}).(string)
+	return id
+}
+
+// loggingTransport emits one structured event per HTTP request.
+type loggingTransport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+	clock  clockwork.Clock
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.clock.Now()
+	resp, err := t.next.RoundTrip(req)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("endpoint", req.URL.Path),
+		slog.Duration("duration", t.clock.Since(start)),
+	}
+	if id := transactionIDFromContext(req.Context()); id != "" {
+		attrs = append(attrs, slog.String("transaction_id", id))
+	}
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.Any("error", err))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if resp.StatusCode >= 500 {
+			level = slog.LevelWarn
+		}
+	}
+	t.logger.LogAttrs(req.Context(), level, "harica request", attrs...)
+	return resp, err
+}
+
+// enableDebug turns on the request and response dumps of resty, redacting
+// all secrets before they reach the logger.
+func (c *Client) enableDebug(r *resty.Client) *resty.Client {
+	return r.SetLogger(restyLogger{c.logger}).
+		OnRequestLog(func(l *resty.RequestLog) error {
+			l.Header = redact.Header(l.Header)
+			l.Body = redact.Body(l.Body)
+			return nil
+		}).
+		OnResponseLog(func(l *resty.ResponseLog) error {
+			l.Header = redact.Header(l.Header)
+			l.Body = redact.Body(l.Body)
+			return nil
+		}).
+		SetDebug(true)
+}
+
+// restyLogger forwards the log output of resty to slog.
+type restyLogger struct {
+	logger *slog.Logger
+}
+
+func (l restyLogger) Errorf(format string, v ...any) {
+	l.logger.Error(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l restyLogger) Warnf(format string, v ...any) {
+	l.logger.Warn(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l restyLogger) Debugf(format string, v ...any) {
+	l.logger.Debug(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "harica_client"
+
+// Metrics holds the Prometheus metrics of one or more clients. A single
+// instance can be shared by all clients of a process; per-account series are
+// labelled with the account email.
+type Metrics struct {
+	requestDuration *prometheus.HistogramVec
+	logins          *prometheus.CounterVec
+	refreshes       *prometheus.CounterVec
+	lastRefresh     *prometheus.GaugeVec
+	tokenExpiry     *prometheus.GaugeVec
+}
+
+// NewMetrics creates the client metrics and registers them on reg.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests to the HARICA portal.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "status"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "logins_total",
+			Help:      "Number of login attempts by result.",
+		}, []string{"account", "result"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "refreshes_total",
+			Help:      "Number of session refreshes, including the initial login, in the background or before a request, by result.",
+		}, []string{"account", "result"}),
+		lastRefresh: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_successful_refresh_timestamp_seconds",
+			Help:      "Unix time of the last successful session refresh or initial login.",
+		}, []string{"account"}),
+		tokenExpiry: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "token_expiry_timestamp_seconds",
+			Help:      "Unix time at which the current session token expires.",
+		}, []string{"account"}),
+	}
+	collectors := []prometheus.Collector{m.requestDuration, m.logins, m.refreshes, m.lastRefresh, m.tokenExpiry}
+	for _, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// WithMetrics records the metrics of the client in m.
+func WithMetrics(m *Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// The following methods are no-ops on a nil receiver, so metrics are optional.
+
+func (m *Metrics) observeLogin(account string, err error) {
+	if m == nil {
+		return
+	}
+	m.logins.WithLabelValues(account, result(err)).Inc()
+}
+
+func (m *Metrics) observeRefresh(account string, err error, at time.Time) {
+	if m == nil {
+		return
+	}
+	m.refreshes.WithLabelValues(account, result(err)).Inc()
+	if err == nil {
+		m.lastRefresh.WithLabelValues(account).Set(float64(at.UnixNano()) / 1e9)
+	}
+}
+
+func (m *Metrics) observeTokenExpiry(account string, exp time.Time) {
+	if m == nil {
+		return
+	}
+	m.tokenExpiry.WithLabelValues(account).Set(float64(exp.Unix()))
+}
+
+// metricsTransport records the duration of every HTTP request.
+type metricsTransport struct {
+	next    http.RoundTripper
+	metrics *Metrics
+	clock   clockwork.Clock
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := t.clock.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	t.metrics.requestDuration.WithLabelValues(req.URL.Path, status).Observe(t.clock.Since(start).Seconds())
+	return resp, err
+}
@@ -0,0 +1,36 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsRecordInitialLogin(t *testing.T) {
+	srv := newPortal(t)
+	reg := prometheus.NewRegistry()
+	metrics, err := client.NewMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := clockwork.NewFakeClockAt(time.Unix(1700000000, 0))
+	newClient(t, srv, requesterEmail, client.WithMetrics(metrics), client.WithClock(clock))
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "harica_client_last_successful_refresh_timestamp_seconds" {
+			continue
+		}
+		if got := family.GetMetric()[0].GetGauge().GetValue(); got != 1700000000 {
+			t.Errorf("last successful refresh = %v, want 1700000000", got)
+		}
+		return
+	}
+	t.Error("last successful refresh is not set after the initial login")
+}
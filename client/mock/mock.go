@@ -0,0 +1,181 @@
+// Package mock provides a hand-written implementation of the client
+// interfaces for unit tests that should not talk to HARICA.
+//
+//	m := &mock.Client{
+//		GetCertificateFunc: func(ctx context.Context, id string) (*models.CertificateResponse, error) {
+//			return &models.CertificateResponse{PemBundle: pemBundle}, nil
+//		},
+//	}
+//	svc := NewService(m)
+//
+// Methods without a function return ErrNotImplemented.
+package mock
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/hm-edu/harica/models"
+)
+
+// ErrNotImplemented is returned by methods whose function field is nil.
+var ErrNotImplemented = errors.New("mock: method not implemented")
+
+// Call records a single method invocation.
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// Client implements all client interfaces by delegating to its function
+// fields. It records all calls and is safe for concurrent use.
+type Client struct {
+	CheckDomainNamesFunc          func(ctx context.Context, domains []string) ([]models.DomainResponse, error)
+	CheckMatchingOrganizationFunc func(ctx context.Context, domains []string) ([]models.OrganizationResponse, error)
+	RequestCertificateFunc        func(ctx context.Context, domains []models.DomainResponse, csr string, transactionType string) (*models.CertificateRequestResponse, error)
+	CancelTransactionFunc         func(ctx context.Context, id string) error
+	RenewCertificateFunc          func(ctx context.Context, id string, req client.RenewalRequest) (*client.Renewal, error)
+	GetPendingReviewsFunc         func(ctx context.Context) ([]models.ReviewResponse, error)
+	ApproveRequestFunc            func(ctx context.Context, id, message, value string) error
+	GetCertificateFunc            func(ctx context.Context, id string) (*models.CertificateResponse, error)
+	WaitForCertificateFunc        func(ctx context.Context, id string) (*models.CertificateResponse, error)
+	RevokeCertificateFunc         func(ctx context.Context, id string, reason client.RevocationReasonName, comment string) error
+	ListTransactionsFunc          func(ctx context.Context, filter client.TransactionFilter, startIndex int) (*client.Page[models.TransactionResponse], error)
+	ListCertificatesFunc          func(ctx context.Context, filter client.TransactionFilter, startIndex int) (*client.Page[models.CertificateSummary], error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var (
+	_ client.Requester         = (*Client)(nil)
+	_ client.Validator         = (*Client)(nil)
+	_ client.CertificateReader = (*Client)(nil)
+	_ client.Waiter            = (*Client)(nil)
+	_ client.Canceller         = (*Client)(nil)
+	_ client.Renewer           = (*Client)(nil)
+	_ client.Revoker           = (*Client)(nil)
+	_ client.Lister            = (*Client)(nil)
+)
+
+// Calls returns the invocations so far, in order.
+func (m *Client) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the invocations of method so far, in order.
+func (m *Client) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (m *Client) record(method string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+func (m *Client) CheckDomainNames(ctx context.Context, domains []string) ([]models.DomainResponse, error) {
+	m.record("CheckDomainNames", domains)
+	if m.CheckDomainNamesFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.CheckDomainNamesFunc(ctx, domains)
+}
+
+func (m *Client) CheckMatchingOrganization(ctx context.Context, domains []string) ([]models.OrganizationResponse, error) {
+	m.record("CheckMatchingOrganization", domains)
+	if m.CheckMatchingOrganizationFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.CheckMatchingOrganizationFunc(ctx, domains)
+}
+
+func (m *Client) RequestCertificate(ctx context.Context, domains []models.DomainResponse, csr string, transactionType string) (*models.CertificateRequestResponse, error) {
+	m.record("RequestCertificate", domains, csr, transactionType)
+	if m.RequestCertificateFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.RequestCertificateFunc(ctx, domains, csr, transactionType)
+}
+
+func (m *Client) CancelTransaction(ctx context.Context, id string) error {
+	m.record("CancelTransaction", id)
+	if m.CancelTransactionFunc == nil {
+		return ErrNotImplemented
+	}
+	return m.CancelTransactionFunc(ctx, id)
+}
+
+func (m *Client) RenewCertificate(ctx context.Context, id string, req client.RenewalRequest) (*client.Renewal, error) {
+	m.record("RenewCertificate", id, req)
+	if m.RenewCertificateFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.RenewCertificateFunc(ctx, id, req)
+}
+
+func (m *Client) GetPendingReviews(ctx context.Context) ([]models.ReviewResponse, error) {
+	m.record("GetPendingReviews")
+	if m.GetPendingReviewsFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetPendingReviewsFunc(ctx)
+}
+
+func (m *Client) ApproveRequest(ctx context.Context, id, message, value string) error {
+	m.record("ApproveRequest", id, message, value)
+	if m.ApproveRequestFunc == nil {
+		return ErrNotImplemented
+	}
+	return m.ApproveRequestFunc(ctx, id, message, value)
+}
+
+func (m *Client) GetCertificate(ctx context.Context, id string) (*models.CertificateResponse, error) {
+	m.record("GetCertificate", id)
+	if m.GetCertificateFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.GetCertificateFunc(ctx, id)
+}
+
+func (m *Client) WaitForCertificate(ctx context.Context, id string) (*models.CertificateResponse, error) {
+	m.record("WaitForCertificate", id)
+	if m.WaitForCertificateFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.WaitForCertificateFunc(ctx, id)
+}
+
+func (m *Client) RevokeCertificate(ctx context.Context, id string, reason client.RevocationReasonName, comment string) error {
+	m.record("RevokeCertificate", id, reason, comment)
+	if m.RevokeCertificateFunc == nil {
+		return ErrNotImplemented
+	}
+	return m.RevokeCertificateFunc(ctx, id, reason, comment)
+}
+
+func (m *Client) ListTransactions(ctx context.Context, filter client.TransactionFilter, startIndex int) (*client.Page[models.TransactionResponse], error) {
+	m.record("ListTransactions", filter, startIndex)
+	if m.ListTransactionsFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.ListTransactionsFunc(ctx, filter, startIndex)
+}
+
+func (m *Client) ListCertificates(ctx context.Context, filter client.TransactionFilter, startIndex int) (*client.Page[models.CertificateSummary], error) {
+	m.record("ListCertificates", filter, startIndex)
+	if m.ListCertificatesFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return m.ListCertificatesFunc(ctx, filter, startIndex)
+}
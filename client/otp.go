@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// OTPProvider supplies the one-time code for the second factor of a login.
+// It is consulted for every login, including re-authentication after the
+// session expired.
+type OTPProvider interface {
+	Code(ctx context.Context) (string, error)
+}
+
+// WithOTPProvider makes the client log in with a second factor obtained from
+// provider. It takes precedence over a TOTP seed of the credentials.
+func WithOTPProvider(provider OTPProvider) Option {
+	return func(c *Client) {
+		c.otp = provider
+	}
+}
+
+// totpGenerator is implemented by providers that derive codes from the
+// current time, so that the client can pass the time of its clock.
+type totpGenerator interface {
+	generate(t time.Time) (string, error)
+}
+
+// otpCode obtains a code from provider, using the clock of c for TOTP
+// generators.
+func (c *Client) otpCode(ctx context.Context, provider OTPProvider) (string, error) {
+	if g, ok := provider.(totpGenerator); ok {
+		return g.generate(c.clock.Now())
+	}
+	return provider.Code(ctx)
+}
+
+// TOTPSeed generates codes from a base32 encoded TOTP secret with the
+// default parameters of 6 digits, SHA-1 and a 30 second period.
+type TOTPSeed string
+
+func (s TOTPSeed) Code(context.Context) (string, error) {
+	return s.generate(time.Now())
+}
+
+func (s TOTPSeed) generate(t time.Time) (string, error) {
+	return totp.GenerateCode(string(s), t)
+}
+
+// TOTPKey generates codes for a key as encoded in otpauth:// URIs, honouring
+// its digits, algorithm and period.
+type TOTPKey struct {
+	key *otp.Key
+}
+
+// NewTOTPKey parses an otpauth://totp/ URI as shown in enrollment QR codes.
+func NewTOTPKey(uri string) (*TOTPKey, error) {
+	key, err := otp.NewKeyFromURL(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, err
+	}
+	if key.Type() != "totp" {
+		return nil, fmt.Errorf("harica: unsupported OTP type %q", key.Type())
+	}
+	return &TOTPKey{key: key}, nil
+}
+
+func (k *TOTPKey) Code(context.Context) (string, error) {
+	return k.generate(time.Now())
+}
+
+func (k *TOTPKey) generate(t time.Time) (string, error) {
+	return totp.GenerateCodeCustom(k.key.Secret(), t, totp.ValidateOpts{
+		Period:    uint(k.key.Period()),
+		Digits:    k.key.Digits(),
+		Algorithm: k.key.Algorithm(),
+	})
+}
+
+// OTPFunc adapts a function to OTPProvider, e.g. to fetch codes from a
+// hardware token or a secrets service.
+type OTPFunc func(ctx context.Context) (string, error)
+
+func (f OTPFunc) Code(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// Stdin reads lines from os.Stdin. Prompts share it, so that input buffered
+// by one of them is not lost for the next.
+var Stdin = NewLineReader(os.Stdin)
+
+// LineReader reads lines from an input shared by several prompts.
+type LineReader struct {
+	in *bufio.Reader
+	// turn is held by the current reader, pending is the outcome of a read
+	// abandoned by a cancelled caller, which the next caller receives.
+	turn    chan struct{}
+	pending chan lineResult
+}
+
+type lineResult struct {
+	line string
+	err  error
+}
+
+// NewLineReader returns a LineReader for in.
+func NewLineReader(in io.Reader) *LineReader {
+	return &LineReader{in: bufio.NewReader(in), turn: make(chan struct{}, 1)}
+}
+
+// ReadLine returns the next line without the line break and surrounding
+// white space. If ctx is done first, the line is kept for the next call.
+func (l *LineReader) ReadLine(ctx context.Context) (string, error) {
+	select {
+	case l.turn <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-l.turn }()
+
+	if l.pending == nil {
+		done := make(chan lineResult, 1)
+		go func() {
+			line, err := l.in.ReadString('\n')
+			if errors.Is(err, io.EOF) && line != "" {
+				err = nil
+			}
+			done <- lineResult{strings.TrimSpace(line), err}
+		}()
+		l.pending = done
+	}
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-l.pending:
+		l.pending = nil
+		return r.line, r.err
+	}
+}
+
+// PromptOTP asks the user for the code on a terminal. Because it blocks until
+// a code is entered, it is best combined with WithBackgroundRefresh(false).
+type PromptOTP struct {
+	// Prompt is written before reading a code. It defaults to
+	// "One-time code: ".
+	Prompt string
+	// In and Out default to Stdin and os.Stderr.
+	In  *LineReader
+	Out io.Writer
+}
+
+func (p PromptOTP) Code(ctx context.Context) (string, error) {
+	in, out, prompt := p.In, p.Out, p.Prompt
+	if in == nil {
+		in = Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+	if prompt == "" {
+		prompt = "One-time code: "
+	}
+	if _, err := io.WriteString(out, prompt); err != nil {
+		return "", err
+	}
+	code, err := in.ReadLine(ctx)
+	if err != nil {
+		return "", err
+	}
+	if code == "" {
+		return "", errors.New("harica: no one-time code entered")
+	}
+	return code, nil
+}
+
+// otpProvider returns the provider for the second factor of a login with
+// creds, or nil if the account does not use one. A TOTP seed may also be
+// given as otpauth:// URI.
+func (c *Client) otpProvider(creds Credentials) (OTPProvider, error) {
+	switch {
+	case c.otp != nil:
+		return c.otp, nil
+	case strings.HasPrefix(creds.TOTPSeed, "otpauth://"):
+		return NewTOTPKey(creds.TOTPSeed)
+	case creds.TOTPSeed != "":
+		return TOTPSeed(creds.TOTPSeed), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,40 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hm-edu/harica/client"
+)
+
+func TestPromptOTPSharesInput(t *testing.T) {
+	in := client.NewLineReader(strings.NewReader("111111\n222222\n"))
+	ctx := context.Background()
+	for _, want := range []string{"111111", "222222"} {
+		prompt := client.PromptOTP{In: in, Out: io.Discard}
+		code, err := prompt.Code(ctx)
+		if err != nil || code != want {
+			t.Fatalf("Code() = %q, %v, want %q", code, err, want)
+		}
+	}
+}
+
+func TestLineReaderKeepsLineAfterCancel(t *testing.T) {
+	r, w := io.Pipe()
+	in := client.NewLineReader(r)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := in.ReadLine(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ReadLine = %v, want context.DeadlineExceeded", err)
+	}
+
+	go w.Write([]byte("yes\n")) //nolint:errcheck
+	line, err := in.ReadLine(context.Background())
+	if err != nil || line != "yes" {
+		t.Fatalf("ReadLine = %q, %v, want %q", line, err, "yes")
+	}
+}
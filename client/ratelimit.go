@@ -0,0 +1,76 @@
+package client
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimiter throttles all requests of the client, including logins,
+// with limiter. The option may be given several times; a request then waits
+// for every limiter. Passing the same limiter to several clients shares its
+// budget between them, e.g. a global limit next to a per-account one:
+//
+//	global := rate.NewLimiter(rate.Limit(10), 10)
+//	a, _ := NewClient(userA, passA, seedA, WithRateLimiter(global), WithRateLimiter(rate.NewLimiter(2, 5)))
+//	b, _ := NewClient(userB, passB, seedB, WithRateLimiter(global), WithRateLimiter(rate.NewLimiter(2, 5)))
+//
+// If HARICA answers 429 Too Many Requests with a Retry-After header, the
+// limiters are paused for that long, so that all clients sharing them back
+// off.
+func WithRateLimiter(limiter *rate.Limiter) Option {
+	return func(c *Client) {
+		c.limiters = append(c.limiters, limiter)
+	}
+}
+
+// rateLimitTransport delays requests until all limiters allow them.
+type rateLimitTransport struct {
+	next     http.RoundTripper
+	limiters []*rate.Limiter
+	clock    clockwork.Clock
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for _, limiter := range t.limiters {
+		if err := limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if d := parseRetryAfter(resp.Header.Get("Retry-After"), t.clock.Now()); d > 0 {
+			// rate.Limiter works on wall time, independent of the client clock.
+			now := time.Now()
+			for _, limiter := range t.limiters {
+				pause(limiter, now, d)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// pause blocks limiter for d after now by reserving the tokens that become
+// available in the meantime. Limiters that are already blocked for longer are
+// left alone.
+func pause(limiter *rate.Limiter, now time.Time, d time.Duration) {
+	limit, burst := limiter.Limit(), limiter.Burst()
+	if limit == rate.Inf || limit <= 0 || burst <= 0 {
+		return
+	}
+	// A request waits until a full token is available.
+	n := int(math.Ceil(limiter.TokensAt(now) - 1 + d.Seconds()*float64(limit)))
+	for n > 0 {
+		k := min(n, burst)
+		if !limiter.ReserveN(now, k).OK() {
+			return
+		}
+		n -= k
+	}
+}
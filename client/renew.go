@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/hm-edu/harica/models"
+)
+
+// renewedTransactionField asks HARICA to link a certificate request to its
+// predecessor. It is not confirmed against a recorded request of the portal,
+// which may ignore it.
+const renewedTransactionField = "renewedTransactionId"
+
+// RenewalRequest describes the key of a renewed certificate.
+type RenewalRequest struct {
+	// CSR is the PEM encoded certificate request for the new certificate.
+	CSR string
+	// Key signs a generated CSR if CSR is empty, e.g. the key of the
+	// predecessor to keep it. If both are empty, a new ECDSA P-256 key is
+	// generated.
+	Key crypto.Signer
+}
+
+// Renewal is the result of RenewCertificate.
+type Renewal struct {
+	// Predecessor is the renewed transaction. Callers should keep this link,
+	// as HARICA may not record it.
+	Predecessor *models.TransactionResponse
+	// Transaction is the new certificate request.
+	Transaction *models.CertificateRequestResponse
+	// Key is the generated key. It is nil if the request contained a CSR or
+	// a key.
+	Key crypto.Signer
+}
+
+// RenewCertificate requests a successor for the certificate issued in
+// transaction id, for the same domains and transaction type. The new
+// transaction has to be reviewed like any other request. The link to the
+// predecessor is returned in the Renewal; asking HARICA to record it as well
+// is best effort.
+func (c *Client) RenewCertificate(ctx context.Context, id string, req RenewalRequest) (_ *Renewal, err error) {
+	ctx = withTransactionID(ctx, id)
+	ctx, span := c.startSpan(ctx, "RenewCertificate", attrTransactionID.String(id))
+	defer endSpan(span, &err)
+
+	predecessor, err := c.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if predecessor.Serial == "" {
+		return nil, fmt.Errorf("harica: transaction %s has no issued certificate to renew", id)
+	}
+	names := make([]string, 0, len(predecessor.Domains))
+	for _, d := range predecessor.Domains {
+		names = append(names, d.Fqdn)
+	}
+
+	renewal := &Renewal{Predecessor: predecessor}
+	csr := req.CSR
+	if csr == "" {
+		key := req.Key
+		if key == nil {
+			if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+				return nil, err
+			}
+			renewal.Key = key
+		}
+		if csr, err = NewCSR(key, names); err != nil {
+			return nil, err
+		}
+	}
+
+	domains, err := c.CheckDomainNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	renewal.Transaction, err = c.requestCertificate(ctx, domains, csr, predecessor.TransactionType, map[string]string{
+		renewedTransactionField: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renewal, nil
+}
+
+// NewCSR returns a PEM encoded certificate request for domains signed by key.
+// The first domain is used as common name.
+func NewCSR(key crypto.Signer, domains []string) (string, error) {
+	if len(domains) == 0 {
+		return "", errors.New("harica: no domains for certificate request")
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Requests that only
+// read data are retried on any retryable failure. Requests that change
+// state, like RequestCertificate or ApproveRequest, are only retried if they
+// provably did not reach HARICA or if a follow-up check shows that the failed
+// attempt did not take effect.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one.
+	// Values below 2 disable retries, except for 429 Too Many Requests, which
+	// is always retried up to DefaultRetryPolicy.MaxAttempts times.
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the delay before the first retry.
+	// It doubles with every further attempt up to MaxBackoff. The actual delay
+	// is randomized between half and the full bound.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryableStatusCodes lists the HTTP status codes considered transient.
+	// 429 Too Many Requests is always retried, respecting Retry-After, with
+	// the backoff of DefaultRetryPolicy if the policy has none.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy retries transient gateway errors up to three times.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:          3,
+	InitialBackoff:       500 * time.Millisecond,
+	MaxBackoff:           10 * time.Second,
+	RetryableStatusCodes: []int{502, 503, 504},
+}
+
+// WithRetry enables retries of failed requests according to policy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// retryable reports whether err is worth another attempt.
+func (p RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			slices.Contains(p.RetryableStatusCodes, apiErr.StatusCode)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return notSent(err) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// maxAttempts returns the total number of attempts for a request that failed
+// with err.
+func (p RetryPolicy) maxAttempts(err error) int {
+	if errors.Is(err, ErrRateLimited) {
+		return max(p.MaxAttempts, DefaultRetryPolicy.MaxAttempts)
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the randomized delay before the given retry (starting at 1).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryDelay returns the delay before the given retry, honouring a
+// Retry-After header of the failed response.
+func (p RetryPolicy) retryDelay(retry int, err error) time.Duration {
+	if errors.Is(err, ErrRateLimited) && p.InitialBackoff <= 0 {
+		p = DefaultRetryPolicy
+	}
+	d := p.backoff(retry)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > d {
+		return apiErr.RetryAfter
+	}
+	return d
+}
+
+// notSent reports whether err guarantees that the request was not processed,
+// e.g. because the connection could not be established or HARICA rejected it
+// with 429 Too Many Requests.
+func notSent(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// sleep waits for d or until ctx is done.
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	t := c.clock.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.Chan():
+		return nil
+	}
+}
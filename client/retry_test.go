@@ -0,0 +1,186 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/hm-edu/harica/fake"
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/time/rate"
+)
+
+// statusCode returns the status code of an *client.APIError, or 0.
+func statusCode(err error) int {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// fastRetry retries quickly enough for tests.
+var fastRetry = client.RetryPolicy{
+	MaxAttempts:          3,
+	InitialBackoff:       time.Millisecond,
+	MaxBackoff:           time.Millisecond,
+	RetryableStatusCodes: []int{502, 503, 504},
+}
+
+func TestRetryReads(t *testing.T) {
+	srv := newPortal(t)
+	c := newClient(t, srv, requesterEmail, client.WithRetry(fastRetry))
+	srv.FailNext(client.CheckDomainNamesPath, http.StatusBadGateway, http.StatusBadGateway)
+	if _, err := c.CheckDomainNames(context.Background(), []string{"example.org"}); err != nil {
+		t.Fatalf("CheckDomainNames: %v", err)
+	}
+}
+
+func TestNoRetryWithoutPolicy(t *testing.T) {
+	srv := newPortal(t)
+	c := newClient(t, srv, requesterEmail)
+	srv.FailNext(client.CheckDomainNamesPath, http.StatusBadGateway)
+	_, err := c.CheckDomainNames(context.Background(), []string{"example.org"})
+	if statusCode(err) != http.StatusBadGateway {
+		t.Fatalf("CheckDomainNames = %v, want 502", err)
+	}
+}
+
+func TestRequestCertificateIsNotReplayed(t *testing.T) {
+	srv := newPortal(t)
+	c := newClient(t, srv, requesterEmail, client.WithRetry(fastRetry))
+	ctx := context.Background()
+	domains, err := c.CheckDomainNames(ctx, []string{"example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr := newCSR(t, "example.org")
+
+	// A 502 may come from a gateway after HARICA processed the request, so
+	// the request must fail after a single attempt and leave the second
+	// failure for the next call.
+	srv.FailNext(client.RequestCertPath, http.StatusBadGateway, http.StatusBadGateway)
+	for range 2 {
+		if _, err := c.RequestCertificate(ctx, domains, csr, "DV"); statusCode(err) != http.StatusBadGateway {
+			t.Fatalf("RequestCertificate = %v, want 502", err)
+		}
+	}
+	if _, err := c.RequestCertificate(ctx, domains, csr, "DV"); err != nil {
+		t.Fatalf("RequestCertificate: %v", err)
+	}
+	if n := len(srv.Transactions()); n != 1 {
+		t.Errorf("%d transactions, want 1", n)
+	}
+}
+
+func TestApproveRequestRetriedIfNotApplied(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail, client.WithRetry(fastRetry))
+	id := request(t, requester, "example.org")
+
+	// The failed attempt did not reach the fake portal, which the follow-up
+	// check of the pending reviews reveals.
+	srv.FailNext(client.UpdateReviewsPath, http.StatusBadGateway)
+	approve(t, validator, id)
+	if tr, _ := srv.Transaction(id); tr.Status != fake.StatusCompleted {
+		t.Errorf("status = %s, want %s", tr.Status, fake.StatusCompleted)
+	}
+}
+
+func TestApproveRequestUnknownReviewIsNotReplayed(t *testing.T) {
+	srv := newPortal(t)
+	validator := newClient(t, srv, validatorEmail, client.WithRetry(fastRetry))
+	ctx := context.Background()
+
+	// Whether an unknown review was approved cannot be checked, so the
+	// original error is returned without a retry.
+	srv.FailNext(client.UpdateReviewsPath, http.StatusBadGateway, http.StatusBadGateway)
+	for range 2 {
+		if err := validator.ApproveRequest(ctx, "unknown", "", ""); statusCode(err) != http.StatusBadGateway {
+			t.Fatalf("ApproveRequest = %v, want 502", err)
+		}
+	}
+}
+
+func TestApproveRequestBeyondFirstPage(t *testing.T) {
+	srv := newPortal(t)
+	srv.PageSize = 2
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail, client.WithRetry(fastRetry))
+	for range 4 {
+		request(t, requester, "example.org")
+	}
+	last := srv.Transactions()[3]
+
+	srv.FailNext(client.UpdateReviewsPath, http.StatusBadGateway)
+	if err := validator.ApproveRequest(context.Background(), last.Reviews[0].ID, "", last.Reviews[0].Value); err != nil {
+		t.Fatalf("ApproveRequest: %v", err)
+	}
+	if tr, _ := srv.Transaction(last.ID); tr.Status != fake.StatusCompleted {
+		t.Errorf("status = %s, want %s", tr.Status, fake.StatusCompleted)
+	}
+}
+
+func TestRateLimitedRetriedWithoutPolicy(t *testing.T) {
+	srv := newPortal(t)
+	clock := clockwork.NewFakeClock()
+	c := newClient(t, srv, requesterEmail, client.WithClock(clock))
+	srv.FailNext(client.CheckDomainNamesPath, http.StatusTooManyRequests)
+
+	go func() {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+	}()
+	if _, err := c.CheckDomainNames(context.Background(), []string{"example.org"}); err != nil {
+		t.Fatalf("CheckDomainNames: %v", err)
+	}
+}
+
+func TestRateLimitedLoginIsNoAuthFailure(t *testing.T) {
+	srv := newPortal(t)
+	srv.FailNext(client.LoginPath, http.StatusTooManyRequests)
+	_, err := client.NewClient(requesterEmail, password, "", client.WithBaseURL(srv.URL), client.WithBackgroundRefresh(false))
+	if !errors.Is(err, client.ErrRateLimited) || errors.Is(err, client.ErrUnauthorized) {
+		t.Fatalf("NewClient = %v, want ErrRateLimited only", err)
+	}
+}
+
+func TestRateLimitedPausesSharedLimiter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for Retry-After")
+	}
+	srv := newPortal(t)
+	limiter := rate.NewLimiter(100, 5)
+	a := newClient(t, srv, requesterEmail, client.WithRateLimiter(limiter))
+	b := newClient(t, srv, requesterEmail, client.WithRateLimiter(limiter))
+	ctx := context.Background()
+
+	srv.FailNext(client.CheckDomainNamesPath, http.StatusTooManyRequests)
+	start := time.Now()
+	done := make(chan error)
+	go func() {
+		_, err := a.CheckDomainNames(ctx, []string{"example.org"})
+		done <- err
+	}()
+	// Wait until the 429 paused the limiter for most of the second before b
+	// sends its request.
+	for limiter.Tokens() > -50 {
+		if time.Since(start) > time.Second {
+			t.Fatal("the 429 did not pause the limiter")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := b.CheckDomainNames(ctx, []string{"example.org"}); err != nil {
+		t.Fatalf("CheckDomainNames: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("second client sent its request after %s, before Retry-After", elapsed)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("CheckDomainNames: %v", err)
+	}
+}
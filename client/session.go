@@ -0,0 +1,325 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// session returns the authenticated resty client and the token it uses.
+func (c *Client) session() (*resty.Client, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client, c.currentToken
+}
+
+// activeSession is like session but fails with ErrClosed once the client has
+// been closed.
+func (c *Client) activeSession() (*resty.Client, string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed || c.client == nil {
+		return nil, "", ErrClosed
+	}
+	return c.client, c.currentToken, nil
+}
+
+// setSession replaces the current session with the one of account.
+func (c *Client) setSession(r *resty.Client, token, account string) {
+	c.mu.Lock()
+	c.client = r
+	c.currentToken = token
+	c.account = account
+	c.mu.Unlock()
+
+	if exp, err := tokenExpiry(token); err == nil {
+		c.metrics.observeTokenExpiry(account, exp)
+	}
+}
+
+// accountName returns the email of the account of the current session.
+func (c *Client) accountName() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.account
+}
+
+// prepareClient logs in if there is no session yet or the current token
+// expires within the next RefreshInterval. A session from the session cache
+// is preferred over a new login. A closed client does not log in again.
+func (c *Client) prepareClient(ctx context.Context) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	if c.isClosed() {
+		return ErrClosed
+	}
+	if _, currentToken := c.session(); currentToken == "" && c.cache != nil {
+		c.restoreSession(ctx)
+	}
+	r, currentToken := c.session()
+	if r == nil || currentToken == "" {
+		return c.login(ctx)
+	}
+	renew, err := c.needsRenewal(currentToken)
+	if err != nil {
+		return err
+	}
+	if renew {
+		return c.login(ctx)
+	}
+	return nil
+}
+
+// needsRenewal reports whether token expires within the next RefreshInterval.
+func (c *Client) needsRenewal(token string) (bool, error) {
+	exp, err := tokenExpiry(token)
+	if err != nil {
+		return false, err
+	}
+	return exp.Before(c.clock.Now().Add(RefreshInterval)), nil
+}
+
+// tokenExpiry returns the exp claim of the JWT token.
+func tokenExpiry(token string) (time.Time, error) {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	exp, err := parsed.Claims.GetExpirationTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if exp == nil {
+		return time.Time{}, errors.New("harica: token has no expiration time")
+	}
+	return exp.Time, nil
+}
+
+// cacheKey identifies an account in the session cache.
+func (c *Client) cacheKey(email string) string {
+	return c.baseURL + "|" + email
+}
+
+// restoreSession installs the cached session of the account, if there is one
+// that is still valid. Callers must hold loginMu.
+func (c *Client) restoreSession(ctx context.Context) {
+	creds, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return
+	}
+	cached, err := c.cache.Load(c.cacheKey(creds.Email))
+	if err != nil {
+		c.logger.Warn("failed to load cached session", slog.Any("error", err))
+		return
+	}
+	if cached == nil {
+		return
+	}
+	if renew, err := c.needsRenewal(cached.Token); err != nil || renew {
+		return
+	}
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return
+	}
+	r := c.newRestyClient()
+	r.GetClient().Jar.SetCookies(u, cached.Cookies)
+	c.setSession(c.authorize(r, cached.Token, cached.VerificationToken), cached.Token, creds.Email)
+}
+
+// storeSession writes the session of r to the session cache.
+func (c *Client) storeSession(email string, r *resty.Client, token, verificationToken string) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return
+	}
+	err = c.cache.Store(c.cacheKey(email), &CachedSession{
+		Token:             token,
+		VerificationToken: verificationToken,
+		Cookies:           r.GetClient().Jar.Cookies(u),
+	})
+	if err != nil {
+		c.logger.Warn("failed to store session in cache", slog.Any("error", err))
+	}
+}
+
+// authorize configures r to send the session tokens with every request.
+func (c *Client) authorize(r *resty.Client, token, verificationToken string) *resty.Client {
+	return r.SetHeaders(map[string]string{"Authorization": token}).
+		SetHeaderVerbatim("RequestVerificationToken", verificationToken)
+}
+
+// relogin authenticates again after the session identified by staleToken
+// was rejected. If another goroutine already replaced that session in the
+// meantime, the new session is reused instead of logging in twice. It fails
+// with ErrClosed if the client was closed in the meantime.
+func (c *Client) relogin(ctx context.Context, staleToken string) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	if c.isClosed() {
+		return ErrClosed
+	}
+	if _, token := c.session(); token != staleToken {
+		return nil
+	}
+	return c.login(ctx)
+}
+
+// login performs a full login and replaces the current session. Callers must
+// hold loginMu.
+func (c *Client) login(ctx context.Context) (err error) {
+	ctx, span := c.startSpan(ctx, "login")
+	defer endSpan(span, &err)
+
+	creds, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+	if creds.Email == "" || creds.Password == "" {
+		return ErrMissingCredentials
+	}
+	defer func() { c.metrics.observeLogin(creds.Email, err) }()
+
+	// The code is obtained first, as prompting for it may take longer than
+	// the verification token is valid.
+	path := LoginPath
+	body := map[string]string{"email": creds.Email, "password": creds.Password}
+	otp, err := c.otpProvider(creds)
+	if err != nil {
+		return err
+	}
+	if otp != nil {
+		code, err := c.otpCode(ctx, otp)
+		if err != nil {
+			return fmt.Errorf("harica: obtaining one-time code: %w", err)
+		}
+		path = LoginPathTotp
+		body["token"] = code
+	}
+	r := c.newRestyClient()
+	verificationToken, err := c.getVerificationToken(ctx, r)
+	if err != nil {
+		return err
+	}
+	resp, err := r.
+		R().SetContext(ctx).
+		SetHeaderVerbatim("RequestVerificationToken", verificationToken).
+		SetHeader("Content-Type", ApplicationJson).
+		SetBody(body).
+		Post(path)
+	if err != nil {
+		return err
+	}
+	if err := c.checkResponse(path, resp); err != nil {
+		return err
+	}
+	tokenResp := strings.Trim(resp.String(), "\"")
+	_, _, err = jwt.NewParser().ParseUnverified(tokenResp, jwt.MapClaims{})
+	if err != nil {
+		apiErr := newAPIError(path, resp, c.clock.Now())
+		apiErr.Message = "login did not return a token"
+		return fmt.Errorf("%w: %w", apiErr, err)
+	}
+	r = r.SetHeaders(map[string]string{"Authorization": tokenResp})
+	token, err := c.getVerificationToken(ctx, r)
+	if err != nil {
+		return err
+	}
+	r = c.authorize(r, tokenResp, token)
+	c.setSession(r, tokenResp, creds.Email)
+	if c.cache != nil {
+		c.storeSession(creds.Email, r, tokenResp, token)
+	}
+	return nil
+}
+
+// request describes a single HARICA API call.
+type request struct {
+	path string
+	// build adds parameters to the request. It may be nil and must be safe to
+	// call for every attempt.
+	build func(*resty.Request) *resty.Request
+	// idempotent requests can be replayed without side effects.
+	idempotent bool
+	// applied reports whether a failed non-idempotent request took effect
+	// anyway. It is consulted before such a request is retried; without it the
+	// request is only retried if it never reached HARICA.
+	applied func(ctx context.Context) (bool, error)
+}
+
+// do performs req according to the retry policy of the client.
+func (c *Client) do(ctx context.Context, req request) (*resty.Response, error) {
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+	if err := c.refreshIfNeeded(ctx); err != nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := c.doOnce(ctx, req)
+		if err == nil || attempt >= c.retry.maxAttempts(err) || !c.retry.retryable(err) {
+			return resp, err
+		}
+		if !req.idempotent && !notSent(err) {
+			if req.applied == nil {
+				return resp, err
+			}
+			applied, checkErr := req.applied(ctx)
+			if checkErr != nil {
+				// We cannot tell whether the request went through, so a
+				// replay could duplicate it.
+				return resp, err
+			}
+			if applied {
+				return resp, nil
+			}
+		}
+		if err := c.sleep(ctx, c.retry.retryDelay(attempt, err)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// doOnce posts req using the current session. If HARICA rejects the session,
+// the client logs in again and replays the request once. Requests issued
+// after Close fail with ErrClosed.
+func (c *Client) doOnce(ctx context.Context, req request) (*resty.Response, error) {
+	send := func(r *resty.Client) (*resty.Response, error) {
+		rr := r.R().SetContext(ctx)
+		if req.build != nil {
+			rr = req.build(rr)
+		}
+		resp, err := rr.Post(req.path)
+		if err != nil {
+			return nil, err
+		}
+		return resp, c.checkResponse(req.path, resp)
+	}
+
+	r, token, err := c.activeSession()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := send(r)
+	var apiErr *APIError
+	if err == nil || !errors.As(err, &apiErr) || !apiErr.IsAuthFailure() {
+		return resp, err
+	}
+	if err := c.relogin(ctx, token); err != nil {
+		return nil, err
+	}
+	r, _, err = c.activeSession()
+	if err != nil {
+		return nil, err
+	}
+	return send(r)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/hm-edu/harica/client"
+
+// Attribute keys used on spans in addition to the semantic conventions.
+const (
+	attrTransactionID = attribute.Key("harica.transaction_id")
+	attrReviewID      = attribute.Key("harica.review_id")
+	attrEndpoint      = attribute.Key("harica.endpoint")
+)
+
+// WithTracerProvider sets the provider used to create spans for every client
+// method and every HTTP request. It defaults to the global provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// startSpan starts the span of a client method.
+func (c *Client) startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if id := transactionIDFromContext(ctx); id != "" {
+		attrs = append(attrs, attrTransactionID.String(id))
+	}
+	return c.tracer.Start(ctx, "harica."+method, trace.WithAttributes(attrs...))
+}
+
+// endSpan records *err on span and ends it. It is meant to be deferred with a
+// pointer to the named error result of a method.
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// tracingTransport creates a client span for every HTTP request.
+type tracingTransport struct {
+	next   http.RoundTripper
+	tracer trace.Tracer
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Hostname()),
+		attribute.String("url.path", req.URL.Path),
+		attrEndpoint.String(req.URL.Path),
+	}
+	if id := transactionIDFromContext(req.Context()); id != "" {
+		attrs = append(attrs, attrTransactionID.String(id))
+	}
+	ctx, span := t.tracer.Start(req.Context(), req.Method+" "+req.URL.Path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	defer span.End()
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+func defaultTracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
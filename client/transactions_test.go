@@ -0,0 +1,345 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/hm-edu/harica/fake"
+)
+
+// fastPoll polls quickly enough for tests.
+var fastPoll = client.PollPolicy{
+	InitialInterval: 5 * time.Millisecond,
+	MaxInterval:     20 * time.Millisecond,
+	Timeout:         5 * time.Second,
+}
+
+// requestCounter counts the HTTP requests of a client.
+type requestCounter struct {
+	n    atomic.Int64
+	next http.RoundTripper
+}
+
+func (c *requestCounter) middleware(next http.RoundTripper) http.RoundTripper {
+	c.next = next
+	return c
+}
+
+func (c *requestCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.n.Add(1)
+	return c.next.RoundTrip(req)
+}
+
+func TestWaitForCertificate(t *testing.T) {
+	srv := newPortal(t)
+	srv.IssuanceDelay = 50 * time.Millisecond
+	requester := newClient(t, srv, requesterEmail, client.WithPollPolicy(fastPoll))
+	validator := newClient(t, srv, validatorEmail)
+
+	id := issue(t, requester, validator, "example.org")
+	if tr, _ := srv.Transaction(id); tr.Status != fake.StatusPending {
+		t.Fatalf("status = %s before the issuance delay", tr.Status)
+	}
+	cert, err := requester.WaitForCertificate(context.Background(), id)
+	if err != nil {
+		t.Fatalf("WaitForCertificate: %v", err)
+	}
+	if cert.PemBundle == "" {
+		t.Error("WaitForCertificate returned no PEM bundle")
+	}
+}
+
+func TestWaitForCertificateTimeout(t *testing.T) {
+	srv := newPortal(t)
+	counter := &requestCounter{}
+	// Unset intervals fall back to DefaultPollPolicy instead of polling in a
+	// tight loop.
+	requester := newClient(t, srv, requesterEmail,
+		client.WithPollPolicy(client.PollPolicy{Timeout: 300 * time.Millisecond}),
+		client.WithTransportMiddleware(counter.middleware))
+	id := request(t, requester, "example.org")
+
+	before := counter.n.Load()
+	_, err := requester.WaitForCertificate(context.Background(), id)
+	if !errors.Is(err, client.ErrIssuanceTimeout) {
+		t.Fatalf("WaitForCertificate = %v, want ErrIssuanceTimeout", err)
+	}
+	if n := counter.n.Load() - before; n > 10 {
+		t.Errorf("WaitForCertificate sent %d requests within 300ms", n)
+	}
+}
+
+func TestWaitForCertificateRejected(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail, client.WithPollPolicy(fastPoll))
+	id := request(t, requester, "example.org")
+	srv.RejectTransaction(id)
+
+	_, err := requester.WaitForCertificate(context.Background(), id)
+	if !errors.Is(err, client.ErrCertificateRejected) {
+		t.Fatalf("WaitForCertificate = %v, want ErrCertificateRejected", err)
+	}
+}
+
+func TestCancelTransaction(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail, client.WithPollPolicy(fastPoll))
+	ctx := context.Background()
+	id := request(t, requester, "example.org")
+
+	if err := requester.CancelTransaction(ctx, id); err != nil {
+		t.Fatalf("CancelTransaction: %v", err)
+	}
+	if tr, _ := srv.Transaction(id); tr.Status != fake.StatusCancelled {
+		t.Errorf("status = %s, want %s", tr.Status, fake.StatusCancelled)
+	}
+	if err := requester.CancelTransaction(ctx, id); !errors.Is(err, client.ErrValidationFailed) {
+		t.Errorf("second CancelTransaction = %v, want ErrValidationFailed", err)
+	}
+	if _, err := requester.WaitForCertificate(ctx, id); !errors.Is(err, client.ErrTransactionCancelled) {
+		t.Errorf("WaitForCertificate = %v, want ErrTransactionCancelled", err)
+	}
+}
+
+func TestCancelIssuedTransaction(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail)
+	id := issue(t, requester, validator, "example.org")
+
+	if err := requester.CancelTransaction(context.Background(), id); !errors.Is(err, client.ErrValidationFailed) {
+		t.Fatalf("CancelTransaction = %v, want ErrValidationFailed", err)
+	}
+	if tr, _ := srv.Transaction(id); tr.Status != fake.StatusCompleted {
+		t.Errorf("status = %s, want %s", tr.Status, fake.StatusCompleted)
+	}
+}
+
+func TestRevokeCertificate(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail)
+	ctx := context.Background()
+	id := issue(t, requester, validator, "example.org")
+
+	tr, _ := srv.Transaction(id)
+	serial := tr.Certificate.SerialNumber.Text(16)
+	found, err := requester.FindCertificateBySerial(ctx, "00:"+strings.ToLower(serial))
+	if err != nil {
+		t.Fatalf("FindCertificateBySerial: %v", err)
+	}
+	if found.TransactionID != id {
+		t.Errorf("FindCertificateBySerial = %s, want %s", found.TransactionID, id)
+	}
+
+	if err := requester.RevokeCertificate(ctx, id, client.ReasonKeyCompromise, "leaked"); err != nil {
+		t.Fatalf("RevokeCertificate: %v", err)
+	}
+	tr, _ = srv.Transaction(id)
+	if !tr.Revoked || tr.RevocationReason != string(client.ReasonKeyCompromise) || tr.RevocationComment != "leaked" {
+		t.Errorf("transaction after revocation = %+v", tr)
+	}
+	cert, err := requester.GetCertificate(ctx, id)
+	if err != nil {
+		t.Fatalf("GetCertificate: %v", err)
+	}
+	if !cert.IsRevoked {
+		t.Error("GetCertificate does not report the revocation")
+	}
+	if err := requester.RevokeCertificate(ctx, id, client.ReasonKeyCompromise, ""); !errors.Is(err, client.ErrValidationFailed) {
+		t.Errorf("second RevokeCertificate = %v, want ErrValidationFailed", err)
+	}
+}
+
+func TestRevokeCertificateUnknownReason(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail)
+	id := issue(t, requester, validator, "example.org")
+
+	if err := requester.RevokeCertificate(context.Background(), id, "because", ""); !errors.Is(err, client.ErrValidationFailed) {
+		t.Fatalf("RevokeCertificate = %v, want ErrValidationFailed", err)
+	}
+	if tr, _ := srv.Transaction(id); tr.Revoked {
+		t.Error("certificate was revoked")
+	}
+}
+
+func TestRenewCertificate(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail)
+	ctx := context.Background()
+	id := issue(t, requester, validator, "www.example.org", "example.org")
+
+	renewal, err := requester.RenewCertificate(ctx, id, client.RenewalRequest{})
+	if err != nil {
+		t.Fatalf("RenewCertificate: %v", err)
+	}
+	if renewal.Key == nil || renewal.Predecessor.TransactionID != id {
+		t.Errorf("RenewCertificate = %+v", renewal)
+	}
+	successor, ok := srv.Transaction(renewal.Transaction.TransactionID)
+	if !ok {
+		t.Fatal("renewal did not create a transaction")
+	}
+	if successor.Predecessor != id || successor.Status != fake.StatusPending || strings.Join(successor.Domains, ",") != "www.example.org,example.org" {
+		t.Errorf("successor = %+v", successor)
+	}
+
+	approve(t, validator, successor.ID)
+	if tr, _ := srv.Transaction(successor.ID); tr.Status != fake.StatusCompleted {
+		t.Errorf("successor status = %s, want %s", tr.Status, fake.StatusCompleted)
+	}
+}
+
+func TestRenewPendingTransaction(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	id := request(t, requester, "example.org")
+
+	if _, err := requester.RenewCertificate(context.Background(), id, client.RenewalRequest{}); err == nil {
+		t.Fatal("RenewCertificate of a pending transaction succeeded")
+	}
+	if n := len(srv.Transactions()); n != 1 {
+		t.Errorf("%d transactions, want 1", n)
+	}
+}
+
+func TestListTransactions(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail)
+	ctx := context.Background()
+
+	var ids []string
+	for i, domain := range []string{"a.example.org", "b.example.org", "c.example.org", "d.example.org", "e.example.org"} {
+		if i%2 == 0 {
+			ids = append(ids, issue(t, requester, validator, domain))
+		} else {
+			ids = append(ids, request(t, requester, domain))
+		}
+	}
+	srv.PageSize = 2
+
+	all, err := requester.AllTransactions(ctx, client.TransactionFilter{})
+	if err != nil {
+		t.Fatalf("AllTransactions: %v", err)
+	}
+	if len(all) != len(ids) {
+		t.Fatalf("AllTransactions returned %d transactions, want %d", len(all), len(ids))
+	}
+	if all[0].TransactionID != ids[len(ids)-1] {
+		t.Errorf("AllTransactions does not start with the most recent transaction")
+	}
+
+	pending, err := requester.AllTransactions(ctx, client.TransactionFilter{Status: client.StatusPending})
+	if err != nil {
+		t.Fatalf("AllTransactions: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Errorf("%d pending transactions, want 2", len(pending))
+	}
+
+	certs, err := requester.AllCertificates(ctx, client.TransactionFilter{Domain: "C.EXAMPLE"})
+	if err != nil {
+		t.Fatalf("AllCertificates: %v", err)
+	}
+	if len(certs) != 1 || certs[0].TransactionID != ids[2] {
+		t.Errorf("AllCertificates = %+v, want transaction %s", certs, ids[2])
+	}
+
+	if _, err := requester.GetTransaction(ctx, "unknown"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetTransaction = %v, want ErrNotFound", err)
+	}
+}
+
+// afterListing calls fn once after the first transaction listing response.
+type afterListing struct {
+	once sync.Once
+	fn   func()
+	next http.RoundTripper
+}
+
+func (a *afterListing) middleware(next http.RoundTripper) http.RoundTripper {
+	a.next = next
+	return a
+}
+
+func (a *afterListing) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := a.next.RoundTrip(req)
+	if err == nil && req.URL.Path == client.MyTransactionsPath {
+		a.once.Do(a.fn)
+	}
+	return resp, err
+}
+
+func TestListTransactionsCreatedWhilePaging(t *testing.T) {
+	srv := newPortal(t)
+	other := newClient(t, srv, requesterEmail)
+	ids := map[string]bool{}
+	for range 6 {
+		ids[request(t, other, "example.org")] = true
+	}
+	srv.PageSize = 2
+
+	// The new transaction moves every older one to the next page, so the
+	// second page starts with the last transaction of the first one.
+	hook := &afterListing{fn: func() { request(t, other, "new.example.org") }}
+	requester := newClient(t, srv, requesterEmail, client.WithTransportMiddleware(hook.middleware))
+	all, err := requester.AllTransactions(context.Background(), client.TransactionFilter{})
+	if err != nil {
+		t.Fatalf("AllTransactions: %v", err)
+	}
+	listed := map[string]bool{}
+	for _, tr := range all {
+		if listed[tr.TransactionID] {
+			t.Errorf("AllTransactions returned %s twice", tr.TransactionID)
+		}
+		listed[tr.TransactionID] = true
+	}
+	for id := range ids {
+		if !listed[id] {
+			t.Errorf("AllTransactions is missing %s", id)
+		}
+	}
+}
+
+func TestFindPendingReview(t *testing.T) {
+	srv := newPortal(t)
+	requester := newClient(t, srv, requesterEmail)
+	validator := newClient(t, srv, validatorEmail)
+	ctx := context.Background()
+	var ids []string
+	for range 5 {
+		ids = append(ids, request(t, requester, "example.org"))
+	}
+	srv.PageSize = 2
+
+	first, err := validator.ListPendingReviews(ctx, 0)
+	if err != nil {
+		t.Fatalf("ListPendingReviews: %v", err)
+	}
+	if len(first.Items) != 2 || first.Next != 2 {
+		t.Errorf("ListPendingReviews = %+v", first)
+	}
+	for _, id := range ids {
+		review, err := validator.FindPendingReview(ctx, id)
+		if err != nil {
+			t.Fatalf("FindPendingReview: %v", err)
+		}
+		if review.TransactionID != id {
+			t.Errorf("FindPendingReview = %s, want %s", review.TransactionID, id)
+		}
+	}
+	if _, err := validator.FindPendingReview(ctx, "unknown"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("FindPendingReview = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// transportConfig collects the HTTP settings shared by the login client and
+// the authenticated client.
+type transportConfig struct {
+	proxy        *url.URL
+	rootCAs      *x509.CertPool
+	certificates []tls.Certificate
+	dialTimeout  time.Duration
+	timeout      time.Duration
+	userAgent    string
+	middleware   []func(http.RoundTripper) http.RoundTripper
+}
+
+// WithProxy sends all requests through the given HTTP proxy instead of the
+// one configured in the environment. Credentials for an authenticating proxy
+// can be passed as user info of the URL.
+func WithProxy(proxy *url.URL) Option {
+	return func(c *Client) {
+		c.transportConfig.proxy = proxy
+	}
+}
+
+// WithRootCAs replaces the system roots used to verify the portal
+// certificate, e.g. to trust a TLS intercepting proxy. To extend the system
+// roots, start from x509.SystemCertPool.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *Client) {
+		c.transportConfig.rootCAs = pool
+	}
+}
+
+// WithClientCertificate presents cert to the server or proxy if it requests
+// a TLS client certificate.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(c *Client) {
+		c.transportConfig.certificates = append(c.transportConfig.certificates, cert)
+	}
+}
+
+// WithDialTimeout limits the time spent establishing a connection.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.transportConfig.dialTimeout = timeout
+	}
+}
+
+// WithTimeout limits the duration of every single HTTP request, including
+// reading the response body.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.transportConfig.timeout = timeout
+	}
+}
+
+// WithUserAgent overrides the User-Agent header of all requests.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.transportConfig.userAgent = userAgent
+	}
+}
+
+// WithTransportMiddleware wraps the HTTP transport that talks to the portal,
+// below retries, logging and tracing. It can be repeated; the first
+// middleware is the innermost one. The cassette package uses it to record and
+// replay HARICA responses.
+func WithTransportMiddleware(middleware func(http.RoundTripper) http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transportConfig.middleware = append(c.transportConfig.middleware, middleware)
+	}
+}
+
+// newTransport builds the transport shared by all HTTP clients of a Client.
+func (t transportConfig) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t.proxy != nil {
+		transport.Proxy = http.ProxyURL(t.proxy)
+	}
+	if t.rootCAs != nil || len(t.certificates) > 0 {
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			RootCAs:      t.rootCAs,
+			Certificates: t.certificates,
+		}
+	}
+	if t.dialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   t.dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		transport.TLSHandshakeTimeout = t.dialTimeout
+	}
+	return transport
+}
+
+// buildTransport stacks the instrumentation of c on top of the configured
+// HTTP transport.
+func (c *Client) buildTransport() http.RoundTripper {
+	var rt http.RoundTripper = c.transportConfig.newTransport()
+	for _, middleware := range c.transportConfig.middleware {
+		rt = middleware(rt)
+	}
+	if c.metrics != nil {
+		rt = &metricsTransport{next: rt, metrics: c.metrics, clock: c.clock}
+	}
+	rt = &loggingTransport{next: rt, logger: c.logger, clock: c.clock}
+	// Throttling happens outside of metrics and logging so that waiting for
+	// the limiter does not count as request latency, but inside the span.
+	if len(c.limiters) > 0 {
+		rt = &rateLimitTransport{next: rt, limiters: c.limiters, clock: c.clock}
+	}
+	return &tracingTransport{next: rt, tracer: c.tracer}
+}
+
+// newRestyClient returns a fresh resty client with its own cookie jar that
+// shares the transport of c.
+func (c *Client) newRestyClient() *resty.Client {
+	r := resty.New().
+		SetBaseURL(c.baseURL).
+		SetTransport(c.transport)
+	if c.transportConfig.timeout > 0 {
+		r.SetTimeout(c.transportConfig.timeout)
+	}
+	if c.transportConfig.userAgent != "" {
+		r.SetHeader("User-Agent", c.transportConfig.userAgent)
+	}
+	if c.debug {
+		r = c.enableDebug(r)
+	}
+	return r
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hm-edu/harica/models"
+)
+
+// Transaction states reported by HARICA.
+const (
+	StatusPending   = "Pending"
+	StatusCompleted = "Completed"
+	StatusRejected  = "Rejected"
+	StatusCancelled = "Cancelled"
+)
+
+// PollPolicy controls how WaitForCertificate polls for issuance. Zero fields
+// fall back to the values of DefaultPollPolicy.
+type PollPolicy struct {
+	// InitialInterval is the delay before the second poll. It doubles with
+	// every further poll up to MaxInterval.
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// Timeout limits the total time spent waiting.
+	Timeout time.Duration
+}
+
+// DefaultPollPolicy waits up to ten minutes for a certificate.
+var DefaultPollPolicy = PollPolicy{
+	InitialInterval: 2 * time.Second,
+	MaxInterval:     30 * time.Second,
+	Timeout:         10 * time.Minute,
+}
+
+// withDefaults fills the unset fields of p from DefaultPollPolicy.
+func (p PollPolicy) withDefaults() PollPolicy {
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = DefaultPollPolicy.InitialInterval
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = DefaultPollPolicy.MaxInterval
+	}
+	p.MaxInterval = max(p.MaxInterval, p.InitialInterval)
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultPollPolicy.Timeout
+	}
+	return p
+}
+
+// WithPollPolicy replaces DefaultPollPolicy for WaitForCertificate.
+func WithPollPolicy(policy PollPolicy) Option {
+	return func(c *Client) {
+		c.poll = policy
+	}
+}
+
+// WaitForCertificate polls the transaction id until its certificate is
+// issued and returns it. It fails with ErrCertificateRejected if a reviewer
+// rejected the request, with ErrTransactionCancelled if it was cancelled and
+// with ErrIssuanceTimeout if the certificate was not issued within the
+// timeout of the poll policy.
+//
+// Rejections and cancellations are detected for the transactions on the
+// first page of the account's listing, which holds the most recent ones.
+func (c *Client) WaitForCertificate(ctx context.Context, id string) (_ *models.CertificateResponse, err error) {
+	ctx = withTransactionID(ctx, id)
+	ctx, span := c.startSpan(ctx, "WaitForCertificate", attrTransactionID.String(id))
+	defer endSpan(span, &err)
+
+	policy := c.poll.withDefaults()
+	deadline := c.clock.Now().Add(policy.Timeout)
+	interval := policy.InitialInterval
+	for {
+		cert, err := c.GetCertificate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if cert.PemBundle != "" {
+			return cert, nil
+		}
+		status, err := c.recentStatus(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := terminalStatus(id, status); err != nil {
+			return nil, err
+		}
+
+		remaining := deadline.Sub(c.clock.Now())
+		if remaining <= 0 {
+			return nil, fmt.Errorf("%w: transaction %s was not issued within %s", ErrIssuanceTimeout, id, policy.Timeout)
+		}
+		if err := c.sleep(ctx, min(interval, remaining)); err != nil {
+			return nil, fmt.Errorf("harica: waiting for transaction %s: %w", id, err)
+		}
+		interval = min(2*interval, policy.MaxInterval)
+	}
+}
+
+// recentStatus returns the status of the transaction id if it is on the
+// first page of the account's transactions, or an empty string otherwise.
+// Unlike GetTransaction, it costs a single request.
+func (c *Client) recentStatus(ctx context.Context, id string) (string, error) {
+	page, err := c.ListTransactions(ctx, TransactionFilter{}, 0)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range page.Items {
+		if t.TransactionID == id {
+			return t.TransactionStatus, nil
+		}
+	}
+	return "", nil
+}
+
+// terminalStatus returns the error for transactions that will never be
+// issued.
+func terminalStatus(id, status string) error {
+	switch status {
+	case StatusRejected:
+		return fmt.Errorf("%w: transaction %s", ErrCertificateRejected, id)
+	case StatusCancelled:
+		return fmt.Errorf("%w: transaction %s", ErrTransactionCancelled, id)
+	}
+	return nil
+}
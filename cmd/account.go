@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/spf13/cobra"
+)
+
+// credentialsPassphraseEnv names the environment variable holding the
+// passphrase for age encrypted credential files.
+const credentialsPassphraseEnv = "HARICA_CREDENTIALS_PASSPHRASE"
+
+// account collects the flags describing the credentials of one HARICA
+// account.
+type account struct {
+	email       string
+	password    string
+	totpSeed    string
+	credentials string
+	otp         string
+	description string
+}
+
+// register adds the account flags to cmd. If name is not empty, it is used as
+// prefix for the flag names, e.g. --requester-email.
+func (a *account) register(cmd *cobra.Command, name, description string) {
+	a.description = description
+	prefix := ""
+	if name != "" {
+		prefix = name + "-"
+	}
+	cmd.Flags().StringVar(&a.email, prefix+"email", "", "Email of "+description)
+	cmd.Flags().StringVar(&a.password, prefix+"password", "", "Password of "+description+" (prefer --"+prefix+"credentials)")
+	cmd.Flags().StringVar(&a.totpSeed, prefix+"totp-seed", "", "TOTP seed of "+description+" (prefer --"+prefix+"credentials)")
+	cmd.Flags().StringVar(&a.credentials, prefix+"credentials", "", "Credential source of "+description+": env:PREFIX, file:DIR, cmd:COMMAND or age:FILE")
+	cmd.Flags().StringVar(&a.otp, prefix+"otp", "", "Second factor of "+description+": prompt, otpauth://URI or cmd:COMMAND (overrides the TOTP seed)")
+}
+
+// provider returns the credential provider selected by the flags.
+func (a *account) provider() (client.CredentialProvider, error) {
+	kind, value, _ := strings.Cut(a.credentials, ":")
+	var provider client.CredentialProvider
+	switch kind {
+	case "":
+		return client.StaticCredentials{Email: a.email, Password: a.password, TOTPSeed: a.totpSeed}, nil
+	case "env":
+		provider = client.EnvCredentials{Prefix: value}
+	case "file":
+		provider = client.NewDirCredentials(value)
+	case "cmd":
+		provider = client.CommandCredentials{Email: a.email, Command: strings.Fields(value)}
+	case "age":
+		provider = client.EncryptedFileCredentials{Path: value, Passphrase: os.Getenv(credentialsPassphraseEnv)}
+	default:
+		return nil, fmt.Errorf("unknown credential source %q", kind)
+	}
+	return emailDefault{provider: provider, email: a.email}, nil
+}
+
+// otpProvider returns the second factor selected by --otp, or nil if the
+// TOTP seed of the credentials is to be used.
+func (a *account) otpProvider() (client.OTPProvider, error) {
+	switch {
+	case a.otp == "":
+		return nil, nil
+	case a.otp == "prompt":
+		return client.PromptOTP{Prompt: "One-time code for " + a.description + ": "}, nil
+	case strings.HasPrefix(a.otp, "otpauth://"):
+		return client.NewTOTPKey(a.otp)
+	case strings.HasPrefix(a.otp, "cmd:"):
+		command := strings.Fields(strings.TrimPrefix(a.otp, "cmd:"))
+		if len(command) == 0 {
+			return nil, errors.New("empty OTP command")
+		}
+		return client.OTPFunc(func(ctx context.Context) (string, error) {
+			out, err := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(out)), nil
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown second factor %q", a.otp)
+}
+
+// client logs in to the account. The options are applied after those derived
+// from the global flags.
+func (a *account) client(ctx context.Context, extra ...client.Option) (*client.Client, error) {
+	provider, err := a.provider()
+	if err != nil {
+		return nil, err
+	}
+	options, err := clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	otp, err := a.otpProvider()
+	if err != nil {
+		return nil, err
+	}
+	if otp != nil {
+		options = append(options, client.WithOTPProvider(otp))
+	}
+	options = append(options, extra...)
+	return client.NewClientWithCredentials(ctx, provider, options...)
+}
+
+// emailDefault fills in the email from the command line if the wrapped
+// provider does not supply one.
+type emailDefault struct {
+	provider client.CredentialProvider
+	email    string
+}
+
+func (e emailDefault) Credentials(ctx context.Context) (client.Credentials, error) {
+	creds, err := e.provider.Credentials(ctx)
+	if err != nil {
+		return creds, err
+	}
+	if creds.Email == "" {
+		creds.Email = e.email
+	}
+	return creds, nil
+}
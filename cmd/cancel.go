@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var cancelAccount account
+
+// cancelCmd withdraws pending certificate requests
+var cancelCmd = &cobra.Command{
+	Use:   "cancel TRANSACTION_ID...",
+	Short: "Cancel pending certificate requests",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		c, err := cancelAccount.client(ctx)
+		if err != nil {
+			slog.Error("failed to create client", slog.Any("error", err))
+			os.Exit(1)
+		}
+		failed := false
+		for _, id := range args {
+			if err := c.CancelTransaction(ctx, id); err != nil {
+				slog.Error("failed to cancel transaction", slog.String("id", id), slog.Any("error", err))
+				failed = true
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Cancelled transaction %s.\n", id)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cancelCmd)
+	cancelAccount.register(cancelCmd, "", "the account")
+}
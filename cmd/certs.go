@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	certsAccount       account
+	certsStatus        string
+	certsDomain        string
+	certsType          string
+	certsIssuedAfter   string
+	certsIssuedBefore  string
+	certsExpiresAfter  string
+	certsExpiresBefore string
+	certsRevoked       bool
+	certsTransactions  bool
+	certsOutput        string
+)
+
+// certsCmd groups the commands working on issued certificates
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Work with the certificates of an account",
+}
+
+// certsListCmd lists certificates or transactions
+var certsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the certificates or transactions of an account",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		filter, err := certsFilter(cmd)
+		if err != nil {
+			slog.Error("invalid filter", slog.Any("error", err))
+			os.Exit(1)
+		}
+		if certsOutput != "table" && certsOutput != "json" {
+			slog.Error("invalid output format", slog.String("output", certsOutput))
+			os.Exit(1)
+		}
+		c, err := certsAccount.client(ctx)
+		if err != nil {
+			slog.Error("failed to create client", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		if certsTransactions {
+			transactions, err := c.AllTransactions(ctx, filter)
+			if err != nil {
+				slog.Error("failed to list transactions", slog.Any("error", err))
+				os.Exit(1)
+			}
+			if certsOutput == "json" {
+				printJSON(transactions)
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTYPE\tSTATUS\tREQUESTED\tSERIAL\tRENEWS\tDOMAINS")
+			for _, t := range transactions {
+				domains := make([]string, 0, len(t.Domains))
+				for _, d := range t.Domains {
+					domains = append(domains, d.Fqdn)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", t.TransactionID, t.TransactionType, t.TransactionStatus, t.RequestedAt, t.Serial, t.ChainedTransactionID, strings.Join(domains, ","))
+			}
+			w.Flush() //nolint:errcheck
+			return
+		}
+
+		certificates, err := c.AllCertificates(ctx, filter)
+		if err != nil {
+			slog.Error("failed to list certificates", slog.Any("error", err))
+			os.Exit(1)
+		}
+		if certsOutput == "json" {
+			printJSON(certificates)
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSERIAL\tTYPE\tISSUED\tEXPIRES\tREVOKED\tDOMAINS")
+		for _, cert := range certificates {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", cert.TransactionID, cert.Serial, cert.TransactionType, cert.IssuedAt, cert.ValidTo, yesNo(cert.IsRevoked), strings.Join(cert.Domains, ","))
+		}
+		w.Flush() //nolint:errcheck
+	},
+}
+
+// certsFilter builds the filter from the flags of cmd.
+func certsFilter(cmd *cobra.Command) (client.TransactionFilter, error) {
+	filter := client.TransactionFilter{
+		Status:          certsStatus,
+		Domain:          certsDomain,
+		TransactionType: certsType,
+	}
+	for _, bound := range []struct {
+		value string
+		flag  string
+		dst   *time.Time
+	}{
+		{certsIssuedAfter, "issued-after", &filter.IssuedAfter},
+		{certsIssuedBefore, "issued-before", &filter.IssuedBefore},
+		{certsExpiresAfter, "expires-after", &filter.ExpiresAfter},
+		{certsExpiresBefore, "expires-before", &filter.ExpiresBefore},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := parseDate(bound.value)
+		if err != nil {
+			return filter, fmt.Errorf("--%s: %w", bound.flag, err)
+		}
+		*bound.dst = t
+	}
+	if cmd.Flags().Changed("revoked") {
+		filter.Revoked = &certsRevoked
+	}
+	return filter, nil
+}
+
+// parseDate accepts a date (2006-01-02) or an RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.Error("failed to encode output", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(certsCmd)
+	certsCmd.AddCommand(certsListCmd)
+	certsAccount.register(certsListCmd, "", "the account")
+	certsListCmd.Flags().StringVar(&certsStatus, "status", "", "Only transactions with this status, e.g. Pending or Completed")
+	certsListCmd.Flags().StringVar(&certsDomain, "domain", "", "Only transactions with a domain containing this text")
+	certsListCmd.Flags().StringVarP(&certsType, "transaction-type", "t", "", "Only transactions of this type, e.g. DV or OV")
+	certsListCmd.Flags().StringVar(&certsIssuedAfter, "issued-after", "", "Only certificates issued after this date")
+	certsListCmd.Flags().StringVar(&certsIssuedBefore, "issued-before", "", "Only certificates issued before this date")
+	certsListCmd.Flags().StringVar(&certsExpiresAfter, "expires-after", "", "Only certificates expiring after this date")
+	certsListCmd.Flags().StringVar(&certsExpiresBefore, "expires-before", "", "Only certificates expiring before this date")
+	certsListCmd.Flags().BoolVar(&certsRevoked, "revoked", false, "Only revoked certificates, or with --revoked=false only valid ones")
+	certsListCmd.Flags().BoolVar(&certsTransactions, "transactions", false, "List all transactions instead of issued certificates")
+	certsListCmd.Flags().StringVarP(&certsOutput, "output", "o", "table", "Output format: table or json")
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var domainValidationsAccount account
+
+// domainValidationsCmd lists the domain validations of the account
+var domainValidationsCmd = &cobra.Command{
+	Use:   "domain-validations",
+	Short: "List the domain validations of the account",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		c, err := domainValidationsAccount.client(ctx)
+		if err != nil {
+			slog.Error("failed to create client", slog.Any("error", err))
+			os.Exit(1)
+		}
+		validations, err := c.GetDomainValidations(ctx)
+		if err != nil {
+			slog.Error("failed to get domain validations", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tMETHOD\tSTATUS\tVALIDATED AT\tVALID TO")
+		for _, v := range validations {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Domain, v.Method, v.Status, v.ValidatedAt, v.ValidTo)
+		}
+		w.Flush() //nolint:errcheck
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(domainValidationsCmd)
+	domainValidationsAccount.register(domainValidationsCmd, "", "the account")
+}
@@ -1,25 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/hm-edu/harica/client"
 	"github.com/spf13/cobra"
 )
 
 var (
-	domains           []string
-	csr               string
-	transactionType   string
-	requesterEmail    string
-	requesterPassword string
-	requesterTOTPSeed string
-	validatorEmail    string
-	validatorPassword string
-	validatorTOTPSeed string
-	debug             bool
+	domains          []string
+	csr              string
+	transactionType  string
+	requesterAccount account
+	validatorAccount account
+	waitTimeout      time.Duration
 )
 
 // genCertCmd represents the genCert command
@@ -27,72 +26,79 @@ var genCertCmd = &cobra.Command{
 	Use: "gen-cert",
 	Run: func(cmd *cobra.Command, args []string) {
 
-		requester, err := client.NewClient(requesterEmail, requesterPassword, requesterTOTPSeed, client.WithDebug(debug))
+		ctx := cmd.Context()
+
+		poll := client.DefaultPollPolicy
+		poll.Timeout = waitTimeout
+		requester, err := requesterAccount.client(ctx, client.WithPollPolicy(poll))
 		if err != nil {
 			slog.Error("failed to create requester client", slog.Any("error", err))
 			os.Exit(1)
 		}
-		validator, err := client.NewClient(validatorEmail, validatorPassword, validatorTOTPSeed, client.WithDebug(debug))
+		validator, err := validatorAccount.client(ctx)
 		if err != nil {
 			slog.Error("failed to create validator client", slog.Any("error", err))
 			os.Exit(1)
 		}
 
-		d, err := requester.CheckDomainNames(domains)
+		d, err := requester.CheckDomainNames(ctx, domains)
 		if err != nil {
 			slog.Error("failed to check domain names", slog.Any("error", err))
 			os.Exit(1)
 		}
-		transaction, err := requester.RequestCertificate(d, csr, transactionType)
+		transaction, err := requester.RequestCertificate(ctx, d, csr, transactionType)
 		if err != nil {
 			slog.Error("failed to request certificate", slog.Any("error", err))
 			os.Exit(1)
 		}
-
-		reviews, err := validator.GetPendingReviews()
-		if err != nil {
-			slog.Error("failed to get pending reviews", slog.Any("error", err))
+		// From here on, failures leave a pending transaction behind unless it
+		// is cancelled.
+		fail := func(msg string, err error) {
+			slog.Error(msg, slog.Any("error", err))
+			if !errors.Is(err, client.ErrCertificateRejected) && !errors.Is(err, client.ErrTransactionCancelled) {
+				cancelTransaction(ctx, requester, transaction.TransactionID)
+			}
 			os.Exit(1)
 		}
 
-		for _, r := range reviews {
-			if r.TransactionID == transaction.TransactionID {
-				for _, s := range r.ReviewGetDTOs {
-					err = validator.ApproveRequest(s.ReviewID, "Auto Approval", s.ReviewValue)
-					if err != nil {
-						slog.Error("failed to approve request", slog.Any("error", err))
-						os.Exit(1)
-					}
-				}
+		review, err := validator.FindPendingReview(ctx, transaction.TransactionID)
+		if err != nil {
+			fail("failed to find pending review", err)
+		}
+		for _, s := range review.ReviewGetDTOs {
+			err = validator.ApproveRequest(ctx, s.ReviewID, "Auto Approval", s.ReviewValue)
+			if err != nil {
+				fail("failed to approve request", err)
 			}
 		}
-		cert, err := requester.GetCertificate(transaction.TransactionID)
+		cert, err := requester.WaitForCertificate(ctx, transaction.TransactionID)
 		if err != nil {
-			slog.Error("failed to get certificate", slog.Any("error", err))
-			os.Exit(1)
+			fail("failed to wait for certificate", err)
 		}
 		fmt.Print(cert.PemBundle)
 	},
 }
 
+// cancelTransaction withdraws the transaction id after a failure. It also
+// runs if ctx was cancelled, e.g. by an interrupt.
+func cancelTransaction(ctx context.Context, c *client.Client, id string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if err := c.CancelTransaction(ctx, id); err != nil {
+		slog.Warn("failed to cancel transaction", slog.String("id", id), slog.Any("error", err))
+		return
+	}
+	slog.Info("cancelled transaction", slog.String("id", id))
+}
+
 func init() {
 	rootCmd.AddCommand(genCertCmd)
 	genCertCmd.Flags().StringSliceVarP(&domains, "domains", "d", []string{}, "Domains to request certificate for")
 	genCertCmd.Flags().StringVar(&csr, "csr", "", "CSR to request certificate with")
 	genCertCmd.Flags().StringVarP(&transactionType, "transaction-type", "t", "DV", "Transaction type to request certificate with")
-	genCertCmd.Flags().StringVar(&requesterEmail, "requester-email", "", "Email of requester")
-	genCertCmd.Flags().StringVar(&requesterPassword, "requester-password", "", "Password of requester")
-	genCertCmd.Flags().StringVar(&requesterTOTPSeed, "requester-totp-seed", "", "TOTP seed of requester")
-	genCertCmd.Flags().StringVar(&validatorEmail, "validator-email", "", "Email of validator")
-	genCertCmd.Flags().StringVar(&validatorPassword, "validator-password", "", "Password of validator")
-	genCertCmd.Flags().StringVar(&validatorTOTPSeed, "validator-totp-seed", "", "TOTP seed of validator")
-	genCertCmd.Flags().BoolVar(&debug, "debug", false, "Enable debug logging")
-	genCertCmd.MarkFlagRequired("domains")             //nolint:errcheck
-	genCertCmd.MarkFlagRequired("csr")                 //nolint:errcheck
-	genCertCmd.MarkFlagRequired("requester-email")     //nolint:errcheck
-	genCertCmd.MarkFlagRequired("requester-password")  //nolint:errcheck
-	genCertCmd.MarkFlagRequired("requester-totp-seed") //nolint:errcheck
-	genCertCmd.MarkFlagRequired("validator-email")     //nolint:errcheck
-	genCertCmd.MarkFlagRequired("validator-password")  //nolint:errcheck
-	genCertCmd.MarkFlagRequired("validator-totp-seed") //nolint:errcheck
+	genCertCmd.Flags().DurationVar(&waitTimeout, "wait-timeout", client.DefaultPollPolicy.Timeout, "Maximum time to wait for the certificate to be issued")
+	requesterAccount.register(genCertCmd, "requester", "requester")
+	validatorAccount.register(genCertCmd, "validator", "validator")
+	genCertCmd.MarkFlagRequired("domains") //nolint:errcheck
+	genCertCmd.MarkFlagRequired("csr")     //nolint:errcheck
 }
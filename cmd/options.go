@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hm-edu/harica/cassette"
+	"github.com/hm-edu/harica/client"
+	"golang.org/x/time/rate"
+)
+
+// sessionCachePassphraseEnv names the environment variable holding the
+// passphrase for the session cache. It is deliberately not a flag.
+const sessionCachePassphraseEnv = "HARICA_SESSION_CACHE_PASSPHRASE"
+
+var (
+	baseURL         string
+	debug           bool
+	maxAttempts     int
+	sessionCache    bool
+	sessionCacheDir string
+	proxy           string
+	caFile          string
+	clientCertFile  string
+	clientKeyFile   string
+	dialTimeout     time.Duration
+	timeout         time.Duration
+	userAgent       string
+	rateLimit       float64
+	rateBurst       int
+	record          string
+)
+
+// recorder is shared by all clients of the process, so that a single cassette
+// covers the whole CLI invocation.
+var recorder = sync.OnceValue(func() *cassette.Recorder {
+	return cassette.NewRecorder(record)
+})
+
+// limiter is shared by all clients of the process, so that the rate limit
+// applies to the CLI invocation as a whole.
+var limiter = sync.OnceValue(func() *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(rateLimit), rateBurst)
+})
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&baseURL, "base-url", client.BaseURL, "Base URL of the HARICA portal")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug logging")
+	rootCmd.PersistentFlags().BoolVar(&sessionCache, "session-cache", false, "Reuse sessions between invocations (encrypted if "+sessionCachePassphraseEnv+" is set)")
+	rootCmd.PersistentFlags().StringVar(&sessionCacheDir, "session-cache-dir", defaultSessionCacheDir(), "Directory of the session cache")
+	rootCmd.PersistentFlags().IntVar(&maxAttempts, "max-attempts", client.DefaultRetryPolicy.MaxAttempts, "Maximum number of attempts for failed requests")
+	rootCmd.PersistentFlags().StringVar(&proxy, "proxy", "", "HTTP proxy URL, overrides HTTPS_PROXY")
+	rootCmd.PersistentFlags().StringVar(&caFile, "ca-file", "", "PEM file with additional trusted root certificates")
+	rootCmd.PersistentFlags().StringVar(&clientCertFile, "client-cert", "", "PEM file with a TLS client certificate")
+	rootCmd.PersistentFlags().StringVar(&clientKeyFile, "client-key", "", "PEM file with the key of the TLS client certificate")
+	rootCmd.PersistentFlags().DurationVar(&dialTimeout, "dial-timeout", 0, "Timeout for establishing connections")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Timeout for a single HTTP request")
+	rootCmd.PersistentFlags().StringVar(&userAgent, "user-agent", "", "User-Agent header to send")
+	rootCmd.PersistentFlags().Float64Var(&rateLimit, "rate-limit", 0, "Maximum requests per second to HARICA (0 disables the limit)")
+	rootCmd.PersistentFlags().IntVar(&rateBurst, "rate-burst", 1, "Number of requests that may exceed --rate-limit in a burst")
+	rootCmd.PersistentFlags().StringVar(&record, "record", "", "Record sanitized requests and responses to this cassette file")
+}
+
+// clientOptions returns the client options derived from the global flags.
+func clientOptions() ([]client.Option, error) {
+	retry := client.DefaultRetryPolicy
+	retry.MaxAttempts = maxAttempts
+	options := []client.Option{
+		// A CLI invocation is short-lived; refreshing before requests is
+		// enough.
+		client.WithBackgroundRefresh(false),
+		client.WithDebug(debug),
+		client.WithBaseURL(baseURL),
+		client.WithRetry(retry),
+		client.WithDialTimeout(dialTimeout),
+		client.WithTimeout(timeout),
+		client.WithUserAgent(userAgent),
+	}
+	if sessionCache {
+		cache := client.NewFileSessionCache(sessionCacheDir, os.Getenv(sessionCachePassphraseEnv))
+		options = append(options, client.WithSessionCache(cache))
+	}
+	if record != "" {
+		options = append(options, client.WithTransportMiddleware(recorder().Middleware))
+	}
+	if rateLimit > 0 {
+		options = append(options, client.WithRateLimiter(limiter()))
+	}
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		options = append(options, client.WithProxy(u))
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		options = append(options, client.WithRootCAs(pool))
+	}
+	if clientCertFile != "" || clientKeyFile != "" {
+		if clientCertFile == "" || clientKeyFile == "" {
+			return nil, errors.New("--client-cert and --client-key must be used together")
+		}
+		cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, client.WithClientCertificate(cert))
+	}
+	return options, nil
+}
+
+func defaultSessionCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "harica", "sessions")
+}
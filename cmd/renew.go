@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renewAccount account
+	renewID      string
+	renewCert    string
+	renewCSR     string
+	renewKey     string
+	renewKeyOut  string
+	renewWait    bool
+)
+
+// renewCmd requests the successor of an issued certificate
+var renewCmd = &cobra.Command{
+	Use:   "renew",
+	Short: "Renew a certificate for the same domains and transaction type",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		req, err := renewalRequest()
+		if err != nil {
+			slog.Error("invalid key or CSR", slog.Any("error", err))
+			os.Exit(1)
+		}
+		c, err := renewAccount.client(ctx)
+		if err != nil {
+			slog.Error("failed to create client", slog.Any("error", err))
+			os.Exit(1)
+		}
+		id := renewID
+		if renewCert != "" {
+			cert, err := readCertificate(renewCert)
+			if err != nil {
+				slog.Error("failed to read certificate", slog.Any("error", err))
+				os.Exit(1)
+			}
+			if req.Key != nil && !publicKeyMatches(cert, req.Key) {
+				slog.Error("the key does not belong to the certificate", slog.String("key", renewKey))
+				os.Exit(1)
+			}
+			t, err := c.FindCertificateBySerial(ctx, fmt.Sprintf("%X", cert.SerialNumber))
+			if err != nil {
+				slog.Error("failed to find certificate", slog.Any("error", err))
+				os.Exit(1)
+			}
+			id = t.TransactionID
+		}
+
+		renewal, err := c.RenewCertificate(ctx, id, req)
+		if err != nil {
+			slog.Error("failed to renew certificate", slog.Any("error", err))
+			os.Exit(1)
+		}
+		if renewal.Key != nil {
+			if err := writeKey(renewKeyOut, renewal.Key); err != nil {
+				slog.Error("failed to write key", slog.Any("error", err))
+				cancelTransaction(ctx, c, renewal.Transaction.TransactionID)
+				os.Exit(1)
+			}
+		}
+		slog.Info("requested renewal", slog.String("predecessor", id), slog.String("id", renewal.Transaction.TransactionID))
+		if !renewWait {
+			fmt.Println(renewal.Transaction.TransactionID)
+			return
+		}
+		cert, err := c.WaitForCertificate(ctx, renewal.Transaction.TransactionID)
+		if err != nil {
+			slog.Error("failed to wait for certificate", slog.Any("error", err))
+			os.Exit(1)
+		}
+		fmt.Print(cert.PemBundle)
+	},
+}
+
+// renewalRequest builds the key material of the renewal from the flags.
+func renewalRequest() (client.RenewalRequest, error) {
+	switch {
+	case renewCSR != "":
+		return client.RenewalRequest{CSR: renewCSR}, nil
+	case renewKey != "":
+		key, err := readKey(renewKey)
+		return client.RenewalRequest{Key: key}, err
+	case renewKeyOut == "":
+		return client.RenewalRequest{}, errors.New("one of --csr, --key or --key-out is required")
+	}
+	if _, err := os.Stat(renewKeyOut); err == nil {
+		return client.RenewalRequest{}, fmt.Errorf("%s already exists", renewKeyOut)
+	}
+	return client.RenewalRequest{}, nil
+}
+
+func readCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate in %s", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// readKey reads a PEM encoded PKCS #8, SEC 1 or PKCS #1 private key.
+func readKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded key in %s", path)
+	}
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key in %s", path)
+	}
+	return signer, nil
+}
+
+// writeKey stores key as PKCS #8 in a new file only readable by the user.
+func writeKey(path string, key crypto.Signer) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+	return f.Close()
+}
+
+func publicKeyMatches(cert *x509.Certificate, key crypto.Signer) bool {
+	pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(key.Public())
+}
+
+func init() {
+	rootCmd.AddCommand(renewCmd)
+	renewAccount.register(renewCmd, "", "the account")
+	renewCmd.Flags().StringVar(&renewID, "id", "", "Transaction ID of the certificate to renew")
+	renewCmd.Flags().StringVar(&renewCert, "cert", "", "PEM file containing the certificate to renew")
+	renewCmd.Flags().StringVar(&renewCSR, "csr", "", "CSR to request the new certificate with")
+	renewCmd.Flags().StringVar(&renewKey, "key", "", "PEM file with the key to reuse for the new certificate")
+	renewCmd.Flags().StringVar(&renewKeyOut, "key-out", "", "File to write a newly generated key to")
+	renewCmd.Flags().BoolVar(&renewWait, "wait", false, "Wait for the new certificate and print it")
+	renewCmd.MarkFlagsOneRequired("id", "cert")
+	renewCmd.MarkFlagsMutuallyExclusive("id", "cert")
+	renewCmd.MarkFlagsMutuallyExclusive("csr", "key", "key-out")
+}
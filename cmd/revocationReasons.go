@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var revocationReasonsAccount account
+
+// revocationReasonsCmd lists the reasons HARICA accepts for revocations
+var revocationReasonsCmd = &cobra.Command{
+	Use:   "revocation-reasons",
+	Short: "List the supported revocation reasons",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		c, err := revocationReasonsAccount.client(ctx)
+		if err != nil {
+			slog.Error("failed to create client", slog.Any("error", err))
+			os.Exit(1)
+		}
+		reasons, err := c.GetRevocationReasons(ctx)
+		if err != nil {
+			slog.Error("failed to get revocation reasons", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tNAME\tDESCRIPTION")
+		for _, r := range reasons {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", r.Code, r.Name, r.Description)
+		}
+		w.Flush() //nolint:errcheck
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(revocationReasonsCmd)
+	revocationReasonsAccount.register(revocationReasonsCmd, "", "the account")
+}
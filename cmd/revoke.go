@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/hm-edu/harica/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	revokeAccount account
+	revokeID      string
+	revokeSerial  string
+	revokeCert    string
+	revokeReason  string
+	revokeComment string
+	revokeYes     bool
+)
+
+// revokeCmd revokes a certificate
+var revokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke a certificate by transaction ID, serial number or PEM file",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		c, err := revokeAccount.client(ctx)
+		if err != nil {
+			slog.Error("failed to create client", slog.Any("error", err))
+			os.Exit(1)
+		}
+		if err := checkRevocationReason(ctx, c, revokeReason); err != nil {
+			slog.Error("invalid revocation reason", slog.Any("error", err))
+			os.Exit(1)
+		}
+		id, err := revocationTarget(ctx, c)
+		if err != nil {
+			slog.Error("failed to find certificate", slog.Any("error", err))
+			os.Exit(1)
+		}
+		cert, err := c.GetCertificate(ctx, id)
+		if err != nil {
+			slog.Error("failed to get certificate", slog.Any("error", err))
+			os.Exit(1)
+		}
+		if cert.IsRevoked {
+			slog.Error("certificate is already revoked", slog.String("id", id))
+			os.Exit(1)
+		}
+		if !revokeYes {
+			question := fmt.Sprintf("Revoke certificate %s (serial %s, %s) with reason %s?", id, cert.Serial, cert.SANS, revokeReason)
+			if !confirm(ctx, question) {
+				fmt.Fprintln(os.Stderr, "Aborted.")
+				os.Exit(1)
+			}
+		}
+		if err := c.RevokeCertificate(ctx, id, client.RevocationReasonName(revokeReason), revokeComment); err != nil {
+			slog.Error("failed to revoke certificate", slog.Any("error", err))
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Revoked certificate %s.\n", id)
+	},
+}
+
+// revocationTarget returns the transaction ID selected by the flags.
+func revocationTarget(ctx context.Context, c *client.Client) (string, error) {
+	serial := revokeSerial
+	if revokeCert != "" {
+		cert, err := readCertificate(revokeCert)
+		if err != nil {
+			return "", err
+		}
+		serial = fmt.Sprintf("%X", cert.SerialNumber)
+	}
+	if serial == "" {
+		return revokeID, nil
+	}
+	t, err := c.FindCertificateBySerial(ctx, serial)
+	if err != nil {
+		return "", err
+	}
+	return t.TransactionID, nil
+}
+
+// checkRevocationReason fails unless HARICA offers a revocation reason with
+// the given name.
+func checkRevocationReason(ctx context.Context, c *client.Client, name string) error {
+	reasons, err := c.GetRevocationReasons(ctx)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		if reason.Name == name {
+			return nil
+		}
+		names = append(names, reason.Name)
+	}
+	return fmt.Errorf("unknown reason %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// confirm asks question on the terminal and reports whether the user agreed.
+func confirm(ctx context.Context, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	// A read error leaves answer empty or incomplete, which counts as no.
+	answer, _ := client.Stdin.ReadLine(ctx)
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	rootCmd.AddCommand(revokeCmd)
+	revokeAccount.register(revokeCmd, "", "the account")
+	revokeCmd.Flags().StringVar(&revokeID, "id", "", "Transaction ID of the certificate")
+	revokeCmd.Flags().StringVar(&revokeSerial, "serial", "", "Hexadecimal serial number of the certificate")
+	revokeCmd.Flags().StringVar(&revokeCert, "cert", "", "PEM file containing the certificate")
+	revokeCmd.Flags().StringVar(&revokeReason, "reason", string(client.ReasonUnspecified), "Revocation reason, see revocation-reasons")
+	revokeCmd.Flags().StringVar(&revokeComment, "comment", "", "Comment stored with the revocation")
+	revokeCmd.Flags().BoolVarP(&revokeYes, "yes", "y", false, "Do not ask for confirmation")
+	revokeCmd.MarkFlagsOneRequired("id", "serial", "cert")
+	revokeCmd.MarkFlagsMutuallyExclusive("id", "serial", "cert")
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var whoamiAccount account
+
+// whoamiCmd prints the identity and roles of an account
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the account, organization and roles of a session",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		c, err := whoamiAccount.client(ctx)
+		if err != nil {
+			slog.Error("failed to create client", slog.Any("error", err))
+			os.Exit(1)
+		}
+		session, err := c.Session()
+		if err != nil {
+			slog.Error("failed to inspect session", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		claims := session.Claims
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Account:\t%s\n", claims.Email)
+		fmt.Fprintf(w, "Organization:\t%s\n", claims.Organization)
+		fmt.Fprintf(w, "Roles:\t%s\n", strings.Join(claims.Roles, ", "))
+		fmt.Fprintf(w, "Expires:\t%s\n", claims.ExpiresAt.Local().Format(time.RFC3339))
+		fmt.Fprintf(w, "Request certificates:\t%s\n", yesNo(session.Capabilities.RequestCertificates))
+		fmt.Fprintf(w, "Review requests:\t%s\n", yesNo(session.Capabilities.ReviewRequests))
+		fmt.Fprintf(w, "Administer enterprise:\t%s\n", yesNo(session.Capabilities.AdministerEnterprise))
+		w.Flush() //nolint:errcheck
+	},
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+	whoamiAccount.register(whoamiCmd, "", "the account")
+}
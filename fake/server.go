@@ -0,0 +1,840 @@
+// Package fake provides an in-process imitation of the HARICA portal for
+// tests. It serves the endpoints used by the client package, keeps accounts
+// and transactions in memory and signs submitted CSRs with a throw-away CA.
+//
+//	srv := fake.NewServer()
+//	defer srv.Close()
+//	srv.AddUser(fake.User{Email: "requester@example.org", Password: "secret", Roles: []fake.Role{fake.RoleRequester}})
+//	c, err := client.NewClient("requester@example.org", "secret", "", client.WithBaseURL(srv.URL))
+package fake
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hm-edu/harica/client"
+	"github.com/hm-edu/harica/models"
+	"github.com/jonboulle/clockwork"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// Role is a permission of a fake account, carried in the role claim of the
+// session token.
+type Role string
+
+const (
+	RoleRequester       Role = client.RoleUser
+	RoleValidator       Role = client.RoleSSLEnterpriseApprover
+	RoleEnterpriseAdmin Role = client.RoleEnterpriseAdmin
+)
+
+// Transaction states used by the fake server.
+const (
+	StatusPending   = client.StatusPending
+	StatusCompleted = client.StatusCompleted
+	StatusRejected  = client.StatusRejected
+	StatusCancelled = client.StatusCancelled
+)
+
+// User is an account of the fake portal. Accounts with a TOTPSeed must log
+// in with a valid one-time code.
+type User struct {
+	Email        string
+	Password     string
+	TOTPSeed     string
+	Organization string
+	Roles        []Role
+}
+
+// Review is the validation of one domain of a transaction.
+type Review struct {
+	ID       string
+	Domain   string
+	Value    string
+	Message  string
+	Reviewed bool
+	Valid    bool
+}
+
+// Transaction is a certificate request known to the fake portal.
+type Transaction struct {
+	ID             string
+	Requester      string
+	Type           string
+	Status         string
+	Domains        []string
+	CSR            string
+	RequestedAt    time.Time
+	Reviews        []Review
+	Certificate    *x509.Certificate
+	CertificatePEM string
+	// Predecessor is the transaction renewed by this one.
+	Predecessor string
+	// IssueAt is the time the certificate is issued once all reviews
+	// succeeded.
+	IssueAt time.Time
+	// Revocation details, set once the certificate was revoked.
+	Revoked           bool
+	RevokedAt         time.Time
+	RevocationReason  string
+	RevocationComment string
+}
+
+// Server is a fake HARICA portal backed by httptest.Server.
+type Server struct {
+	// URL is the base URL of the portal, suitable for client.WithBaseURL.
+	URL string
+	// TokenLifetime is the validity of issued session tokens.
+	TokenLifetime time.Duration
+	// CertificateLifetime is the validity of issued certificates.
+	CertificateLifetime time.Duration
+	// PageSize is the number of transactions returned per listing request.
+	// Transaction listings start with the most recent transaction, reviews
+	// with the oldest.
+	PageSize int
+	// IssuanceDelay is the time between the last successful review and the
+	// issuance of the certificate.
+	IssuanceDelay time.Duration
+	// Clock is the time of the portal, used for session tokens, one-time
+	// codes and certificates. Setting a clockwork.FakeClock different from
+	// the one of the client simulates clock skew.
+	Clock clockwork.Clock
+
+	srv *httptest.Server
+	ca  *ca
+
+	mu             sync.Mutex
+	key            []byte
+	users          map[string]User
+	antiforgery    map[string]bool
+	loggedOut      map[string]bool
+	transactions   map[string]*Transaction
+	order          []string
+	invalidDomains map[string]bool
+	validations    []models.DomainValidation
+	organization   models.OrganizationResponse
+	failures       map[string][]int
+	logins         int
+}
+
+// NewServer starts a fake portal. It must be closed with Close.
+func NewServer() *Server {
+	authority, err := newCA()
+	if err != nil {
+		panic(fmt.Sprintf("fake: creating CA: %v", err))
+	}
+	s := &Server{
+		TokenLifetime:       time.Hour,
+		CertificateLifetime: 90 * 24 * time.Hour,
+		PageSize:            25,
+		Clock:               clockwork.NewRealClock(),
+		ca:                  authority,
+		key:                 randomKey(),
+		users:               map[string]User{},
+		antiforgery:         map[string]bool{},
+		loggedOut:           map[string]bool{},
+		transactions:        map[string]*Transaction{},
+		invalidDomains:      map[string]bool{},
+		failures:            map[string][]int{},
+		organization: models.OrganizationResponse{
+			ID:               uuid.NewString(),
+			OrganizationName: "Fake Organization",
+			Country:          "DE",
+			Dn:               "O=Fake Organization,C=DE",
+			IsBaseDomain:     true,
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handlePortal)
+	mux.HandleFunc("POST "+client.LoginPath, s.handleLogin)
+	mux.HandleFunc("POST "+client.LoginPathTotp, s.handleLogin)
+	mux.HandleFunc("POST "+client.LogoutPath, s.authenticated(s.handleLogout))
+	mux.HandleFunc("POST "+client.RevocationReasonsPath, s.authenticated(s.handleRevocationReasons))
+	mux.HandleFunc("POST "+client.DomainValidationsPath, s.authenticated(s.handleDomainValidations))
+	mux.HandleFunc("POST "+client.CheckMatchingOrgPath, s.authenticated(s.handleCheckMatchingOrganization))
+	mux.HandleFunc("POST "+client.CheckDomainNamesPath, s.authenticated(s.handleCheckDomainNames))
+	mux.HandleFunc("POST "+client.RequestCertPath, s.authenticated(s.handleRequestCertificate))
+	mux.HandleFunc("POST "+client.GetCertificatePath, s.authenticated(s.handleGetCertificate))
+	mux.HandleFunc("POST "+client.ReviewablePath, s.authenticated(s.handleReviewable))
+	mux.HandleFunc("POST "+client.UpdateReviewsPath, s.authenticated(s.handleUpdateReviews))
+	mux.HandleFunc("POST "+client.RevokeCertificatePath, s.authenticated(s.handleRevokeCertificate))
+	mux.HandleFunc("POST "+client.MyTransactionsPath, s.authenticated(s.handleMyTransactions))
+	mux.HandleFunc("POST "+client.CancelTransactionPath, s.authenticated(s.handleCancelTransaction))
+
+	s.srv = httptest.NewServer(s.injectFailures(mux))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// CACertificate returns the root certificate that signs issued certificates.
+func (s *Server) CACertificate() *x509.Certificate {
+	return s.ca.cert
+}
+
+// AddUser creates or replaces an account.
+func (s *Server) AddUser(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[u.Email] = u
+}
+
+// SetOrganization replaces the organization returned for matching checks.
+func (s *Server) SetOrganization(org models.OrganizationResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.organization = org
+}
+
+// RejectDomain makes CheckDomainNames report domain as invalid.
+func (s *Server) RejectDomain(domain string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invalidDomains[domain] = true
+}
+
+// AddDomainValidation adds an entry to the GetDomainValidations response.
+func (s *Server) AddDomainValidation(v models.DomainValidation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validations = append(s.validations, v)
+}
+
+// FailNext makes the next len(statuses) requests to path fail with the given
+// status codes, in order, before they are processed. Responses with 429 Too
+// Many Requests ask the client to retry after one second.
+func (s *Server) FailNext(path string, statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], statuses...)
+}
+
+// ExpireSessions invalidates all issued session tokens, forcing clients to
+// log in again.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = randomKey()
+}
+
+// RejectTransaction rejects the pending transaction id as if a reviewer had
+// declined it.
+func (s *Server) RejectTransaction(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.transactions[id]; ok && t.Status == StatusPending {
+		t.Status = StatusRejected
+	}
+}
+
+// Logins returns the number of successful logins.
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+// Transaction returns a copy of the transaction with the given ID.
+func (s *Server) Transaction(id string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transactions[id]
+	if !ok {
+		return Transaction{}, false
+	}
+	cp := *t
+	cp.Reviews = slices.Clone(t.Reviews)
+	return cp, true
+}
+
+// Transactions returns copies of all transactions in the order they were
+// requested.
+func (s *Server) Transactions() []Transaction {
+	s.mu.Lock()
+	ids := slices.Clone(s.order)
+	s.mu.Unlock()
+	result := make([]Transaction, 0, len(ids))
+	for _, id := range ids {
+		if t, ok := s.Transaction(id); ok {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func (s *Server) injectFailures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		pending := s.failures[r.URL.Path]
+		status := 0
+		if len(pending) > 0 {
+			status = pending[0]
+			s.failures[r.URL.Path] = pending[1:]
+		}
+		s.mu.Unlock()
+		if status != 0 {
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handlePortal(w http.ResponseWriter, r *http.Request) {
+	token := hex.EncodeToString(randomKey())
+	s.mu.Lock()
+	s.antiforgery[token] = true
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>HARICA</title></head>
+<body><form><input name="__RequestVerificationToken" type="hidden" value="%s" /></form></body>
+</html>`, html.EscapeString(token))
+}
+
+// validAntiforgery reports whether the request carries an issued token.
+func (s *Server) validAntiforgery(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.antiforgery[r.Header.Get("RequestVerificationToken")]
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !s.validAntiforgery(r) {
+		http.Error(w, "The antiforgery token could not be validated.", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Token    string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	user, ok := s.users[body.Email]
+	s.mu.Unlock()
+	if !ok || user.Password != body.Password {
+		writeJSON(w, http.StatusBadRequest, "Invalid credentials")
+		return
+	}
+	if user.TOTPSeed != "" {
+		if r.URL.Path != client.LoginPathTotp || !s.validTOTP(body.Token, user.TOTPSeed) {
+			writeJSON(w, http.StatusBadRequest, "Invalid two-factor code")
+			return
+		}
+	}
+	token, err := s.issueToken(user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	s.logins++
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, token)
+}
+
+// validTOTP checks code against seed at the time of the portal, accepting
+// one period of skew like totp.Validate.
+func (s *Server) validTOTP(code, seed string) bool {
+	ok, err := totp.ValidateCustom(code, seed, s.Clock.Now(), totp.ValidateOpts{
+		Period:    30,
+		Skew:      1,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	return err == nil && ok
+}
+
+func (s *Server) issueToken(u User) (string, error) {
+	roles := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		roles = append(roles, string(role))
+	}
+	now := s.Clock.Now()
+	claims := jwt.MapClaims{
+		"jti":          uuid.NewString(),
+		"sub":          u.Email,
+		"email":        u.Email,
+		"organization": u.Organization,
+		"role":         roles,
+		"iat":          now.Unix(),
+		"exp":          now.Add(s.TokenLifetime).Unix(),
+	}
+	s.mu.Lock()
+	key := s.key
+	s.mu.Unlock()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+// authenticated wraps handlers of endpoints that require a session.
+func (s *Server) authenticated(next func(http.ResponseWriter, *http.Request, User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		key := s.key
+		s.mu.Unlock()
+		token, err := jwt.Parse(r.Header.Get("Authorization"), func(*jwt.Token) (any, error) {
+			return key, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithTimeFunc(s.Clock.Now))
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		email, _ := token.Claims.GetSubject()
+		s.mu.Lock()
+		user, ok := s.users[email]
+		loggedOut := s.loggedOut[token.Raw]
+		s.mu.Unlock()
+		if !ok || loggedOut {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !s.validAntiforgery(r) {
+			http.Error(w, "The antiforgery token could not be validated.", http.StatusBadRequest)
+			return
+		}
+		next(w, r, user)
+	}
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, _ User) {
+	s.mu.Lock()
+	s.loggedOut[r.Header.Get("Authorization")] = true
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func hasRole(u User, role Role) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// revocationReasons are the reasons accepted by the fake portal.
+var revocationReasons = []models.RevocationReason{
+	{Code: 0, Name: string(client.ReasonUnspecified), Description: "Unspecified"},
+	{Code: 1, Name: string(client.ReasonKeyCompromise), Description: "The private key has been compromised"},
+	{Code: 3, Name: string(client.ReasonAffiliationChanged), Description: "The subject's affiliation has changed"},
+	{Code: 4, Name: string(client.ReasonSuperseded), Description: "The certificate has been replaced"},
+	{Code: 5, Name: string(client.ReasonCessationOfOperation), Description: "The certificate is no longer needed"},
+}
+
+func (s *Server) handleRevocationReasons(w http.ResponseWriter, _ *http.Request, _ User) {
+	writeJSON(w, http.StatusOK, revocationReasons)
+}
+
+func (s *Server) handleDomainValidations(w http.ResponseWriter, _ *http.Request, _ User) {
+	s.mu.Lock()
+	validations := slices.Clone(s.validations)
+	s.mu.Unlock()
+	if validations == nil {
+		validations = []models.DomainValidation{}
+	}
+	writeJSON(w, http.StatusOK, validations)
+}
+
+func (s *Server) handleCheckMatchingOrganization(w http.ResponseWriter, r *http.Request, _ User) {
+	var domains []client.Domain
+	if err := json.NewDecoder(r.Body).Decode(&domains); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	org := s.organization
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, []models.OrganizationResponse{org})
+}
+
+func (s *Server) handleCheckDomainNames(w http.ResponseWriter, r *http.Request, _ User) {
+	var domains []client.Domain
+	if err := json.NewDecoder(r.Body).Decode(&domains); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]models.DomainResponse, 0, len(domains))
+	for _, d := range domains {
+		resp := models.DomainResponse{
+			Domain:       d.Domain,
+			IsValid:      !s.invalidDomains[d.Domain],
+			IsWildcard:   strings.HasPrefix(d.Domain, "*."),
+			CanRequestOV: true,
+		}
+		if !resp.IsValid {
+			resp.ErrorMessage = "Domain is not allowed"
+		}
+		result = append(result, resp)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleRequestCertificate(w http.ResponseWriter, r *http.Request, u User) {
+	if !hasRole(u, RoleRequester) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var domains []models.DomainResponse
+	if err := json.Unmarshal([]byte(r.FormValue("domains")), &domains); err != nil || len(domains) == 0 {
+		writeJSON(w, http.StatusBadRequest, "No domains given")
+		return
+	}
+	names := make([]string, 0, len(domains))
+	for _, d := range domains {
+		names = append(names, d.Domain)
+	}
+	csr := r.FormValue("csr")
+	if _, _, err := s.ca.sign(csr, names, s.Clock.Now(), time.Minute); err != nil {
+		writeJSON(w, http.StatusBadRequest, "Invalid CSR: "+err.Error())
+		return
+	}
+
+	t := &Transaction{
+		ID:          uuid.NewString(),
+		Requester:   u.Email,
+		Type:        r.FormValue("transactionType"),
+		Status:      StatusPending,
+		Domains:     names,
+		CSR:         csr,
+		RequestedAt: s.Clock.Now(),
+		Predecessor: r.FormValue("renewedTransactionId"),
+	}
+	for _, name := range names {
+		t.Reviews = append(t.Reviews, Review{ID: uuid.NewString(), Domain: name, Value: name})
+	}
+	s.mu.Lock()
+	if t.Predecessor != "" {
+		predecessor, ok := s.transactions[t.Predecessor]
+		if !ok || predecessor.Requester != u.Email || predecessor.Certificate == nil {
+			s.mu.Unlock()
+			writeJSON(w, http.StatusBadRequest, "The renewed certificate does not exist")
+			return
+		}
+	}
+	s.transactions[t.ID] = t
+	s.order = append(s.order, t.ID)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, models.CertificateRequestResponse{TransactionID: t.ID})
+}
+
+func (s *Server) handleReviewable(w http.ResponseWriter, r *http.Request, u User) {
+	if !hasRole(u, RoleValidator) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var req models.ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := []models.ReviewResponse{}
+	for _, id := range s.order {
+		t := s.transactions[id]
+		if req.Status != "" && t.Status != req.Status {
+			continue
+		}
+		result = append(result, s.reviewResponse(t))
+	}
+	start := min(max(req.StartIndex, 0), len(result))
+	end := min(start+s.PageSize, len(result))
+	writeJSON(w, http.StatusOK, result[start:end])
+}
+
+// reviewResponse converts t for the validator view. Callers must hold mu.
+func (s *Server) reviewResponse(t *Transaction) models.ReviewResponse {
+	resp := models.ReviewResponse{
+		TransactionID:       t.ID,
+		TransactionTypeName: t.Type,
+		TransactionType:     t.Type,
+		TransactionStatus:   t.Status,
+		UserEmail:           t.Requester,
+		User:                t.Requester,
+		RequestedAt:         t.RequestedAt.Format(time.RFC3339),
+		Organization:        s.organization.OrganizationName,
+		HasReview:           true,
+	}
+	if t.Predecessor != "" {
+		resp.ChainedTransactionID = t.Predecessor
+	}
+	for _, d := range t.Domains {
+		resp.Domains = append(resp.Domains, models.Domains{Fqdn: d})
+	}
+	for _, review := range t.Reviews {
+		resp.ReviewGetDTOs = append(resp.ReviewGetDTOs, models.ReviewGetDTOs{
+			ReviewID:    review.ID,
+			IsValidated: review.Valid,
+			IsReviewed:  review.Reviewed,
+			CreatedAt:   t.RequestedAt.Format(time.RFC3339),
+			ReviewValue: review.Value,
+		})
+	}
+	return resp
+}
+
+func (s *Server) handleUpdateReviews(w http.ResponseWriter, r *http.Request, u User) {
+	if !hasRole(u, RoleValidator) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reviewID := r.FormValue("reviewId")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.transactions {
+		for i := range t.Reviews {
+			review := &t.Reviews[i]
+			if review.ID != reviewID {
+				continue
+			}
+			if t.Status != StatusPending || review.Reviewed {
+				writeJSON(w, http.StatusBadRequest, "Review is not pending")
+				return
+			}
+			review.Reviewed = true
+			review.Valid = r.FormValue("isValid") == "true"
+			review.Message = r.FormValue("reviewMessage")
+			if err := s.advance(t); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, "Review not found")
+}
+
+// advance moves t on once all of its reviews are done. Callers must hold mu.
+func (s *Server) advance(t *Transaction) error {
+	for _, review := range t.Reviews {
+		if !review.Reviewed {
+			return nil
+		}
+		if !review.Valid {
+			t.Status = StatusRejected
+			return nil
+		}
+	}
+	t.IssueAt = s.Clock.Now().Add(s.IssuanceDelay)
+	return s.issueDue(t)
+}
+
+// issueDue issues the certificate of t if its issuance time has come.
+// Callers must hold mu.
+func (s *Server) issueDue(t *Transaction) error {
+	if t.Status != StatusPending || t.IssueAt.IsZero() || s.Clock.Now().Before(t.IssueAt) {
+		return nil
+	}
+	cert, certPEM, err := s.ca.sign(t.CSR, t.Domains, s.Clock.Now(), s.CertificateLifetime)
+	if err != nil {
+		return err
+	}
+	t.Certificate = cert
+	t.CertificatePEM = certPEM
+	t.Status = StatusCompleted
+	return nil
+}
+
+func (s *Server) handleGetCertificate(w http.ResponseWriter, r *http.Request, u User) {
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transactions[body.ID]
+	if !ok || (t.Requester != u.Email && !hasRole(u, RoleValidator)) {
+		writeJSON(w, http.StatusNotFound, "Certificate not found")
+		return
+	}
+	if err := s.issueDue(t); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.certificateResponse(t))
+}
+
+func (s *Server) handleRevokeCertificate(w http.ResponseWriter, r *http.Request, u User) {
+	var req models.RevocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !slices.ContainsFunc(revocationReasons, func(reason models.RevocationReason) bool { return reason.Name == req.Name }) {
+		writeJSON(w, http.StatusBadRequest, "Unknown revocation reason")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transactions[req.TransactionID]
+	if !ok || (t.Requester != u.Email && !hasRole(u, RoleEnterpriseAdmin)) {
+		writeJSON(w, http.StatusNotFound, "Certificate not found")
+		return
+	}
+	if t.Certificate == nil {
+		writeJSON(w, http.StatusBadRequest, "The certificate has not been issued")
+		return
+	}
+	if t.Revoked {
+		writeJSON(w, http.StatusBadRequest, "The certificate is already revoked")
+		return
+	}
+	t.Revoked = true
+	t.RevokedAt = s.Clock.Now()
+	t.RevocationReason = req.Name
+	t.RevocationComment = req.Notes
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleCancelTransaction(w http.ResponseWriter, r *http.Request, u User) {
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transactions[body.ID]
+	if !ok || (t.Requester != u.Email && !hasRole(u, RoleEnterpriseAdmin)) {
+		writeJSON(w, http.StatusNotFound, "Transaction not found")
+		return
+	}
+	if err := s.issueDue(t); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if t.Status != StatusPending {
+		writeJSON(w, http.StatusBadRequest, "Only pending transactions can be cancelled")
+		return
+	}
+	t.Status = StatusCancelled
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleMyTransactions(w http.ResponseWriter, r *http.Request, u User) {
+	var req models.TransactionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Like the portal, the listing starts with the most recent transaction.
+	result := []models.TransactionResponse{}
+	for _, id := range slices.Backward(s.order) {
+		t := s.transactions[id]
+		if err := s.issueDue(t); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if t.Requester != u.Email || (req.Status != "" && t.Status != req.Status) {
+			continue
+		}
+		result = append(result, s.transactionResponse(t))
+	}
+	start := min(max(req.StartIndex, 0), len(result))
+	end := min(start+s.PageSize, len(result))
+	writeJSON(w, http.StatusOK, result[start:end])
+}
+
+// transactionResponse converts t for transaction listings. Callers must hold mu.
+func (s *Server) transactionResponse(t *Transaction) models.TransactionResponse {
+	resp := models.TransactionResponse{
+		TransactionID:        t.ID,
+		TransactionType:      t.Type,
+		TransactionStatus:    t.Status,
+		UserEmail:            t.Requester,
+		RequestedAt:          t.RequestedAt.Format(time.RFC3339),
+		IsRevoked:            t.Revoked,
+		ChainedTransactionID: t.Predecessor,
+	}
+	for _, d := range t.Domains {
+		resp.Domains = append(resp.Domains, models.Domains{Fqdn: d})
+	}
+	if t.Certificate != nil {
+		resp.IssuedAt = t.Certificate.NotBefore.Format(time.RFC3339)
+		resp.CertificateValidTo = t.Certificate.NotAfter.Format(time.RFC3339)
+		resp.Serial = fmt.Sprintf("%X", t.Certificate.SerialNumber)
+	}
+	if t.Revoked {
+		resp.RevokedAt = t.RevokedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// certificateResponse converts t for GetCertificate. Callers must hold mu.
+func (s *Server) certificateResponse(t *Transaction) models.CertificateResponse {
+	resp := models.CertificateResponse{
+		TransactionID: t.ID,
+		SANS:          strings.Join(t.Domains, ","),
+	}
+	if t.Certificate == nil {
+		return resp
+	}
+	resp.Certificate = t.CertificatePEM
+	resp.PemBundle = t.CertificatePEM + s.ca.pem
+	resp.IssuerCertificate = s.ca.pem
+	resp.DN = t.Certificate.Subject.String()
+	resp.IssuerDN = t.Certificate.Issuer.String()
+	resp.Serial = fmt.Sprintf("%X", t.Certificate.SerialNumber)
+	resp.ValidFrom = t.Certificate.NotBefore.Format(time.RFC3339)
+	resp.ValidTo = t.Certificate.NotAfter.Format(time.RFC3339)
+	resp.KeyType = t.Certificate.PublicKeyAlgorithm.String()
+	resp.AuthorizationDomains = resp.SANS
+	if t.Revoked {
+		resp.IsRevoked = true
+		resp.RevokedAt = t.RevokedAt.Format(time.RFC3339)
+		resp.RevocationCode = t.RevocationReason
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+func randomKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
@@ -0,0 +1,110 @@
+// Package redact removes credentials and session tokens from HTTP headers and
+// bodies exchanged with the HARICA portal, so that they can be logged or
+// stored.
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces redacted values.
+const Placeholder = "[REDACTED]"
+
+// sensitiveHeaders carry credentials or session tokens.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"RequestVerificationToken",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// sensitiveFields are JSON keys and form fields whose values must not be
+// logged or recorded. Matching is case-insensitive.
+var sensitiveFields = []string{"password", "token", "csr", "__RequestVerificationToken"}
+
+var (
+	jwtPattern      = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	inputTag        = regexp.MustCompile(`(?i)<input\b[^>]*>`)
+	antiforgeryName = regexp.MustCompile(`(?i)\bname\s*=\s*["']?__RequestVerificationToken\b`)
+	valueAttribute  = regexp.MustCompile(`(?i)(\bvalue\s*=\s*)("[^"]*"|'[^']*'|[^\s>]+)`)
+	multipartField  = regexp.MustCompile(`(?is)(name="(?:` + strings.Join(sensitiveFields, "|") + `)"\r?\n(?:[^\r\n]+\r?\n)*\r?\n).*?(\r?\n--)`)
+)
+
+// Header returns a copy of h with the values of sensitive headers replaced.
+func Header(h http.Header) http.Header {
+	h = h.Clone()
+	// Some headers are set verbatim and therefore not in canonical form.
+	for key := range h {
+		for _, name := range sensitiveHeaders {
+			if strings.EqualFold(key, name) {
+				h[key] = []string{Placeholder}
+			}
+		}
+	}
+	return h
+}
+
+// Body removes passwords, one-time codes, tokens and CSRs from a request or
+// response body. JSON bodies are rewritten structurally, other bodies are
+// redacted based on patterns for multipart forms, the portal HTML and JWTs.
+func Body(body string) string {
+	return BodyWithTokens(body, func(string) string { return Placeholder })
+}
+
+// BodyWithTokens is like Body but replaces every JWT found in body with the
+// result of replace instead of the placeholder.
+func BodyWithTokens(body string, replace func(token string) string) string {
+	var v any
+	if err := json.Unmarshal([]byte(body), &v); err == nil {
+		if out, err := json.Marshal(redactValue(v, replace)); err == nil {
+			return string(out)
+		}
+	}
+	body = multipartField.ReplaceAllString(body, "${1}"+Placeholder+"${2}")
+	body = inputTag.ReplaceAllStringFunc(body, redactAntiforgeryInput)
+	return jwtPattern.ReplaceAllStringFunc(body, replace)
+}
+
+// redactAntiforgeryInput removes the value of an input tag holding the
+// antiforgery token, whatever the order of its attributes.
+func redactAntiforgeryInput(tag string) string {
+	if !antiforgeryName.MatchString(tag) {
+		return tag
+	}
+	return valueAttribute.ReplaceAllString(tag, `${1}"`+Placeholder+`"`)
+}
+
+func redactValue(v any, replace func(string) string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if isSensitiveField(key) {
+				v[key] = Placeholder
+			} else {
+				v[key] = redactValue(value, replace)
+			}
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = redactValue(value, replace)
+		}
+		return v
+	case string:
+		return jwtPattern.ReplaceAllStringFunc(v, replace)
+	}
+	return v
+}
+
+func isSensitiveField(name string) bool {
+	for _, field := range sensitiveFields {
+		if strings.EqualFold(name, field) {
+			return true
+		}
+	}
+	return false
+}
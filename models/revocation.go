@@ -0,0 +1,13 @@
+package models
+
+type RevocationReason struct {
+	Code        int    `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RevocationRequest struct {
+	TransactionID string `json:"transactionId"`
+	Name          string `json:"name"`
+	Notes         string `json:"notes"`
+}
@@ -0,0 +1,36 @@
+package models
+
+type TransactionsRequest struct {
+	StartIndex     int    `json:"startIndex"`
+	Status         string `json:"status"`
+	FilterPostDTOs []any  `json:"filterPostDTOs"`
+}
+
+type TransactionResponse struct {
+	TransactionID      string    `json:"transactionId"`
+	TransactionType    string    `json:"transactionType"`
+	TransactionStatus  string    `json:"transactionStatus"`
+	UserEmail          string    `json:"userEmail"`
+	Domains            []Domains `json:"domains"`
+	RequestedAt        string    `json:"requestedAt"`
+	IssuedAt           string    `json:"issuedAt,omitempty"`
+	CertificateValidTo string    `json:"certificateValidTo,omitempty"`
+	Serial             string    `json:"serial,omitempty"`
+	IsRevoked          bool      `json:"isRevoked"`
+	RevokedAt          string    `json:"revokedAt,omitempty"`
+	// ChainedTransactionID is the transaction renewed by this one, if HARICA
+	// recorded the link.
+	ChainedTransactionID string `json:"chainedTransactionId,omitempty"`
+}
+
+// CertificateSummary describes an issued certificate in listings.
+type CertificateSummary struct {
+	TransactionID   string   `json:"transactionId"`
+	TransactionType string   `json:"transactionType"`
+	Serial          string   `json:"serial"`
+	Domains         []string `json:"domains"`
+	IssuedAt        string   `json:"issuedAt"`
+	ValidTo         string   `json:"validTo"`
+	IsRevoked       bool     `json:"isRevoked"`
+	RevokedAt       string   `json:"revokedAt,omitempty"`
+}
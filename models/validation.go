@@ -0,0 +1,9 @@
+package models
+
+type DomainValidation struct {
+	Domain      string `json:"domain"`
+	Method      string `json:"validationMethod"`
+	Status      string `json:"status"`
+	ValidatedAt string `json:"validatedAt"`
+	ValidTo     string `json:"validTo"`
+}